@@ -4,10 +4,18 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+
+	"github.com/gurgeous/vectro/internal"
 )
 
 type Args struct {
 	noInit bool
+	theme  string
+	// non-interactive mode, read tokens from stdin (-e) or the command line
+	eval   bool
+	tokens []string
 }
 
 func ParseArgs(args []string) Args {
@@ -17,16 +25,39 @@ func ParseArgs(args []string) Args {
 	f := flag.NewFlagSet("vectro", flag.ExitOnError)
 	f.Usage = func() {
 		fmt.Printf("vectro - the rpn calculator • %s • %s\n", version, date)
-		fmt.Println("There are no command line options. It's a calculator.")
+		fmt.Println("Run with no arguments for the calculator, or evaluate rpn and print the stack:")
+		fmt.Println()
+		fmt.Println("  vectro 3 4 + 2 '*'")
+		fmt.Println("  echo '3 4 + 2 *' | vectro -e")
+		fmt.Println()
+		fmt.Println("Use --theme to pick a theme (catppuccin, solarized, mono), or your own from")
+		fmt.Println("~/.config/vectro/themes/NAME.yml")
 	}
 	f.BoolVar(&a.noInit, "q", false, "disable initialization")
+	f.BoolVar(&a.eval, "e", false, "evaluate rpn from stdin")
+	f.StringVar(&a.theme, "theme", "default", "theme name or file")
 	f.BoolVar(&v, "v", false, "show version")
 	f.BoolVar(&v, "version", false, "show version")
 
+	// flags stop at the first negative number, like vectro -1 2 +
+	var tokens []string
+	if ii := slices.IndexFunc(args, isNegative); ii != -1 {
+		args, tokens = args[:ii], args[ii:]
+	}
 	_ = f.Parse(args)
 	if v {
 		f.Usage()
 		os.Exit(0)
 	}
+	a.tokens = append(f.Args(), tokens...)
+	if len(a.tokens) > 0 {
+		a.eval = true
+	}
 	return a
 }
+
+// -1 or -2.5e3 (or -3d) is a value, not a flag
+func isNegative(arg string) bool {
+	_, err := internal.ParseValue(arg, internal.Dec)
+	return strings.HasPrefix(arg, "-") && err == nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/gurgeous/vectro/internal"
+)
+
+//
+// The stack browser, for working on values below level 1. Opened with the up
+// arrow, and the stack pane scrolls to follow the cursor. While browsing:
+//
+//   up/down     move the cursor
+//   enter/e     edit the value
+//   d/delete    delete the value
+//   K/J         move the value up or down
+//   p           copy the value to the top
+//   y           yank the value
+//   esc         done
+//
+// Down from level 1 is done too.
+//
+
+// defaults, see Keymap
+var BrowseKeys = []string{"up"}
+
+// the input is editing a stack level, see onPendingKey
+const pendingEdit = "EDIT"
+
+type browser struct {
+	visible bool
+	// the level under the cursor, 1 is the top of the stack
+	level int
+	// the level at the top of the stack pane
+	top int
+}
+
+func (m *Model) openBrowser() {
+	if m.c.Empty() {
+		return
+	}
+	m.browser = browser{visible: true, level: 1, top: internal.StackSize}
+	m.moveBrowser(1)
+}
+
+func (m *Model) closeBrowser() {
+	m.browser = browser{}
+}
+
+func (m *Model) onBrowserKey(msg tea.KeyMsg) (tea.Cmd, error) {
+	var cmd tea.Cmd
+	level := m.browser.level
+	switch msg.String() {
+	case "up", "k":
+		m.moveBrowser(level + 1)
+	case "down", "j":
+		if level == 1 {
+			m.closeBrowser()
+			break
+		}
+		m.moveBrowser(level - 1)
+	case "enter", "e":
+		v, _ := m.c.Level(level)
+		m.openPrompt(pendingEdit, "")
+		m.input.SetValue(v.String())
+		m.input.CursorEnd()
+	case "d", "delete", "backspace":
+		if err := m.c.DeleteLevel(level); err != nil {
+			return cmd, err
+		}
+		m.moveBrowser(min(level, m.c.Len()))
+	case "K", "shift+up":
+		if level < m.c.Len() {
+			if err := m.c.SwapLevels(level, level+1); err != nil {
+				return cmd, err
+			}
+			m.moveBrowser(level + 1)
+		}
+	case "J", "shift+down":
+		if level > 1 {
+			if err := m.c.SwapLevels(level, level-1); err != nil {
+				return cmd, err
+			}
+			m.moveBrowser(level - 1)
+		}
+	case "p":
+		// follow the value as it moves up
+		if err := m.c.PickLevel(level); err != nil {
+			return cmd, err
+		}
+		m.moveBrowser(level + 1)
+	case "y":
+		if err := m.c.YankLevel(level); err != nil {
+			return cmd, err
+		}
+		m.say = "yanked to clipboard"
+	case "esc":
+		m.closeBrowser()
+	}
+	return cmd, nil
+}
+
+// move the cursor to level, and scroll so it's visible
+func (m *Model) moveBrowser(level int) {
+	if m.c.Empty() {
+		m.closeBrowser()
+		return
+	}
+	level = max(min(level, m.c.Len()), 1)
+	top := m.browser.top
+	top = max(top, level)
+	top = min(top, level+internal.StackSize-1)
+	top = max(min(top, m.c.Len()), internal.StackSize)
+	m.browser.level, m.browser.top = level, top
+}
+
+// replace the value under the cursor with the edited input. The prompt
+// starts with Value.String, which is always decimal, so parse it that way too
+func (m *Model) editLevel(str string) error {
+	v, err := m.c.ParseRadix(str, internal.Dec)
+	if err != nil {
+		return err
+	}
+	return m.c.SetLevel(m.browser.level, v)
+}
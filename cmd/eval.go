@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gurgeous/vectro/internal"
+)
+
+//
+// Non-interactive mode. Run tokens through a fresh calculator and print the
+// resulting stack, one value per line. Returns the exit code.
+//
+
+func Eval(args Args, stdin io.Reader, stdout, stderr io.Writer) int {
+	tokens := args.tokens
+	if len(tokens) == 0 {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "vectro: %s\n", err.Error())
+			return 1
+		}
+		tokens = strings.Fields(string(data))
+	}
+
+	c := internal.NewCalculator()
+	if err := c.RunTokens(tokens); err != nil {
+		fmt.Fprintf(stderr, "vectro: %s\n", err.Error())
+		return 1
+	}
+	for _, s := range c.GetStackString() {
+		fmt.Fprintln(stdout, s)
+	}
+	return 0
+}
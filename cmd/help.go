@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+
+	"github.com/gurgeous/vectro/internal"
+)
+
+//
+// The help pane, generated from internal.Commands and grouped by category.
+// Keys are wrapped in **stars**. Scroll with pgup/pgdn if it doesn't fit.
+//
+
+// how far pgup/pgdn scroll
+const HelpPageSize = 10
+
+func helpText(km Keymap) string {
+	keys := func(name string) string { return strings.Join(km.Keys(name), " ") }
+	lines := []string{
+		"Vectro - the rpn calculator. To enter a number, start typing and then press enter.",
+		"",
+		fmt.Sprintf("**%s**   enter a number or color", keys(actionNumber)),
+		fmt.Sprintf("**%s**   command palette", keys(actionPalette)),
+		fmt.Sprintf("**%s**   infix expression, like (1 + sqrt(5)) / 2", keys(actionInfix)),
+		fmt.Sprintf("**%s**   browse the stack. **e** edit, **d** delete, **K J** move, **p** copy to top, **y** yank, **esc** done", keys(actionBrowse)),
+		"**pgup pgdown**   scroll help",
+		fmt.Sprintf("**%s**   quit", keys(actionQuit)),
+	}
+	for _, category := range internal.Categories {
+		commands := lo.Filter(internal.Commands, func(c internal.Command, _ int) bool { return c.Category == category })
+		keyed, unkeyed := lo.FilterReject(commands, func(c internal.Command, _ int) bool { return len(km.Keys(c.Name)) > 0 })
+		lines = append(lines, "", internal.HelpCategoryStyle.Render(category))
+		for _, command := range keyed {
+			lines = append(lines, fmt.Sprintf("**%s**   %s", keys(command.Name), command.Desc))
+		}
+		if len(unkeyed) > 0 {
+			names := lo.Map(unkeyed, func(c internal.Command, _ int) string { return strings.ToLower(c.Name) })
+			lines = append(lines, "also "+strings.Join(names, ", "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) scrollHelp(delta int) {
+	n := strings.Count(helpText(m.keys), "\n")
+	m.helpScroll = max(min(m.helpScroll+delta, n), 0)
+}
+
+func (m Model) help(style lipgloss.Style) string {
+	// wrap, then scroll/clip
+	w := style.GetWidth() - style.GetHorizontalPadding()
+	h := max(style.GetHeight()-style.GetVerticalPadding(), 0)
+	wrapped := lipgloss.NewStyle().Width(w).Render(helpText(m.keys))
+	lines := strings.Split(wrapped, "\n")
+	start := min(m.helpScroll, max(len(lines)-h, 0))
+	lines = lines[start:min(start+h, len(lines))]
+	return internal.StyleBetweenStars(strings.Join(lines, "\n"), internal.HelpKeyStyle)
+}
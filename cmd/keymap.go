@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/gurgeous/vectro/internal"
+)
+
+//
+// Key bindings. The defaults come from Command.key plus a few special actions
+// (quit, palette, number, infix, browse). A preset and then the config file can rebind any of
+// them, like this:
+//
+//   preset: vi
+//   keys:
+//     sqrt: [r, "@"]
+//     radix: R
+//     quit: [q, ctrl+c]
+//
+// Rebinding replaces all of the old keys for that command. Two commands with
+// the same key is an error.
+//
+
+const configPath = "vectro/config.yml"
+
+// special actions, which aren't commands
+const (
+	actionBrowse  = "BROWSE"
+	actionInfix   = "INFIX"
+	actionNumber  = "NUMBER"
+	actionPalette = "PALETTE"
+	actionQuit    = "QUIT"
+)
+
+var actions = []string{actionBrowse, actionInfix, actionNumber, actionPalette, actionQuit}
+
+// preset keymaps, applied before the config file
+var Presets = map[string]map[string][]string{
+	"vi": {
+		"CLEAR": {"D"},
+		"DROP":  {"backspace", "d"},
+		"REDO":  {"ctrl+r", "Z"},
+		"UNDO":  {"u", "z"},
+	},
+	"hp": {
+		"-ROT":  {"v"},
+		"CLEAR": {"esc", "ctrl+l"},
+		"NEG":   {"n", "c"},
+		"SWAP":  {"s", "\\"},
+	},
+}
+
+type Keymap struct {
+	// key => command or action name
+	byKey map[string]string
+	// command or action name => keys
+	byName map[string][]string
+}
+
+type config struct {
+	Preset string             `yaml:"preset"`
+	Keys   map[string]keyList `yaml:"keys"`
+}
+
+// either "r" or [r, "@"]
+type keyList []string
+
+func (k *keyList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*k = keyList{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(k))
+}
+
+func defaultBindings() map[string][]string {
+	bindings := map[string][]string{
+		actionBrowse:  BrowseKeys,
+		actionInfix:   InfixKeys,
+		actionNumber:  NumberKeys,
+		actionPalette: PaletteKeys,
+		actionQuit:    QuitKeys,
+	}
+	for _, command := range internal.Commands {
+		if command.Key() != "" {
+			bindings[command.Name] = []string{command.Key()}
+		}
+	}
+	return bindings
+}
+
+func DefaultKeymap() Keymap {
+	return lo.Must(NewKeymap(defaultBindings()))
+}
+
+// build a keymap from name => keys, checking for conflicts
+func NewKeymap(bindings map[string][]string) (Keymap, error) {
+	km := Keymap{byKey: map[string]string{}, byName: map[string][]string{}}
+	for _, name := range slices.Sorted(maps.Keys(bindings)) {
+		for _, key := range bindings[name] {
+			if other, ok := km.byKey[key]; ok && other != name {
+				return Keymap{}, fmt.Errorf("key %q is bound to both %s and %s", key, other, name)
+			}
+			km.byKey[key] = name
+		}
+		km.byName[name] = bindings[name]
+	}
+	return km, nil
+}
+
+// load keymap from the config file, if any
+func LoadKeymap() (Keymap, error) {
+	path, err := xdg.SearchConfigFile(configPath)
+	if err != nil {
+		return DefaultKeymap(), nil // no config
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Keymap{}, err
+	}
+	km, err := ParseKeymap(data)
+	if err != nil {
+		return Keymap{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return km, nil
+}
+
+func ParseKeymap(data []byte) (Keymap, error) {
+	var config config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return Keymap{}, err
+	}
+
+	bindings := defaultBindings()
+	if config.Preset != "" {
+		preset, ok := Presets[config.Preset]
+		if !ok {
+			return Keymap{}, fmt.Errorf("unknown preset %q, try %s", config.Preset, strings.Join(slices.Sorted(maps.Keys(Presets)), " or "))
+		}
+		maps.Copy(bindings, preset)
+	}
+	for name, keys := range config.Keys {
+		name = strings.ToUpper(name)
+		if _, ok := internal.CommandsByName[name]; !ok && !slices.Contains(actions, name) {
+			return Keymap{}, fmt.Errorf("unknown command %q", strings.ToLower(name))
+		}
+		if slices.Contains(keys, "") {
+			return Keymap{}, errors.New("empty key for " + strings.ToLower(name))
+		}
+		bindings[name] = keys
+	}
+	return NewKeymap(bindings)
+}
+
+// the command (or action) bound to a key
+func (km Keymap) Lookup(key string) (string, bool) {
+	name, ok := km.byKey[key]
+	return name, ok
+}
+
+// the keys bound to a command (or action)
+func (km Keymap) Keys(name string) []string {
+	return km.byName[name]
+}
+
+// all of the bound keys
+func (km Keymap) Bound() []string {
+	return slices.Collect(maps.Keys(km.byKey))
+}
+
+func (km Keymap) Is(key, action string) bool {
+	return km.byKey[key] == action
+}
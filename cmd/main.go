@@ -1,11 +1,10 @@
 package main
 
 import (
-	_ "embed"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
-	"regexp"
 	"slices"
 	"strings"
 
@@ -13,11 +12,12 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/samber/lo"
-	"github.com/shopspring/decimal"
 
 	"github.com/gurgeous/vectro/internal"
 )
 
+const InputPlaceholder = "enter number..."
+
 //nolint:recvcheck // bubbletea required Update
 type Model struct {
 	args Args
@@ -32,6 +32,16 @@ type Model struct {
 	// text input, and is it visible?
 	input        textinput.Model
 	inputVisible bool
+	// command waiting for an argument from the text input, see Command.Arg
+	pending string
+	// key bindings, see Keymap
+	keys Keymap
+	// command palette
+	palette palette
+	// stack browser
+	browser browser
+	// help pane scroll position, in lines
+	helpScroll int
 	// vhs mode (demo.tape)
 	vhs       bool
 	vhsTyping bool
@@ -50,21 +60,30 @@ func InitModelWithArgs(args Args) Model {
 		input: func() textinput.Model {
 			input := textinput.New()
 			input.Focus()
-			input.Placeholder = "enter number..."
+			input.Placeholder = InputPlaceholder
 			input.Width = 20
 			input.Cursor.Style = internal.CursorStyle
 			return input
 		}(),
-		vhs: os.Getenv("VHS") != "",
+		palette: palette{input: newPaletteInput()},
+		vhs:     os.Getenv("VHS") != "",
 	}
 
 	if m.vhs {
 		m.args.noInit = true
 	}
+	m.c.SetLocale(Locale())
+	m.setKeymap(DefaultKeymap())
 
 	return m
 }
 
+// macros can't BIND keys the keymap is using
+func (m *Model) setKeymap(keys Keymap) {
+	m.keys = keys
+	m.c.SetBoundKeys(keys.Bound())
+}
+
 func (m Model) Init() tea.Cmd {
 	if !m.args.noInit {
 		Load(m.c)
@@ -72,6 +91,16 @@ func (m Model) Init() tea.Cmd {
 	return textinput.Blink
 }
 
+// the locale for numbers, like "de_DE.UTF-8"
+func Locale() string {
+	for _, env := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if locale := os.Getenv(env); locale != "" {
+			return locale
+		}
+	}
+	return ""
+}
+
 //
 // Update
 //
@@ -89,12 +118,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// paste?
 		if msg.Paste {
-			m.paste(string(msg.Runes))
+			if err := m.paste(string(msg.Runes)); err != nil {
+				m.err = err.Error()
+			}
 			return m, nil
 		}
 
-		// quit?
-		if slices.Contains(QuitKeys, msg.String()) {
+		// quit? (but "q" could be part of a prompt or palette search)
+		typing := m.pending != "" || m.palette.visible
+		if m.keys.Is(msg.String(), actionQuit) && (!typing || msg.Type != tea.KeyRunes) {
 			if !m.args.noInit {
 				Save(m.c)
 			}
@@ -127,9 +159,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 // handle a keypress
 //
 
+// defaults, see Keymap
 var (
+	// these keys prompt for an infix expression
+	InfixKeys = []string{"'", "="}
 	// these keys show the numeric input
-	NumberKeys = []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "."}
+	NumberKeys = []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", ".", "#"}
 	// these keys quit
 	QuitKeys = []string{"q", "ctrl+c", "ctrl+q"}
 )
@@ -138,21 +173,45 @@ func (m *Model) onKey(msg tea.KeyMsg) (tea.Cmd, error) {
 	var cmd tea.Cmd
 
 	key := msg.String()
-	if command, ok := internal.CommandsByKey[key]; ok {
-		return cmd, m.run(command.Name)
+	if m.pending != "" {
+		return m.onPendingKey(msg)
+	}
+	if m.palette.visible {
+		return m.onPaletteKey(msg)
+	}
+	if m.browser.visible {
+		return m.onBrowserKey(msg)
+	}
+	if key == "pgup" || key == "pgdown" {
+		m.scrollHelp(lo.Ternary(key == "pgup", -HelpPageSize, HelpPageSize))
+		return cmd, nil
+	}
+	name, _ := m.keys.Lookup(key)
+	if name == actionPalette && !m.inputAccepts(key) {
+		m.openPalette()
+		return cmd, nil
+	}
+	if name == actionBrowse && !m.inputVisible {
+		m.openBrowser()
+		return cmd, nil
+	}
+	if name == actionInfix && !m.inputAccepts(key) {
+		if err := m.enter(false); err != nil {
+			return cmd, err
+		}
+		m.openPrompt(actionInfix, "expression, like (1 + sqrt(5)) / 2...")
+		return cmd, nil
+	}
+	if _, ok := internal.CommandsByName[name]; ok && !m.inputAccepts(key) {
+		return cmd, m.run(name)
+	}
+	if macro, ok := m.c.MacroByKey(key); ok && !m.inputAccepts(key) {
+		return cmd, m.run(macro.Name)
 	}
 
 	// non-input keys
-	if !m.inputVisible {
-		if key == "backspace" {
-			return cmd, m.run(internal.DROP)
-		}
-		if key == "enter" {
-			return cmd, m.run(internal.DUP)
-		}
-		if slices.Contains(NumberKeys, key) {
-			m.inputVisible = true
-		}
+	if !m.inputVisible && (name == actionNumber || m.inputAccepts(key)) {
+		m.inputVisible = true
 	}
 
 	// input keys
@@ -171,10 +230,10 @@ func (m *Model) onKey(msg tea.KeyMsg) (tea.Cmd, error) {
 //
 // Note that with responsive sizing various boxes can be hidden.
 //
-// 111 333  1=stack
+// 111 555  1=stack
 // 111 333  2=history
 // 222 333  3=help
-// 222 333
+// 222 333  5=registers
 // 4444444  4=status
 //
 //
@@ -199,6 +258,14 @@ func (m Model) View() string {
 	}
 	// border + padding.vert + stack + text + border
 	stackHeight := internal.StackStyle.GetVerticalPadding() + 1 + internal.StackSize + 1 + 1
+	// border + padding.vert + registers
+	registers := m.c.GetRegisters()
+	registersHeight := internal.PaneStyle.GetVerticalFrameSize() + len(registers)
+	box5 := internal.NewBox(0, 0)
+	if len(registers) > 0 && box3.GetHeight()-registersHeight >= 12 {
+		// room for registers? steal from help
+		box5, box3 = box3.CutTop(registersHeight)
+	}
 	box1, box2 := boxLeft.CutTop(stackHeight)
 	if box2.GetHeight() < 5 {
 		// too short? hide history
@@ -211,6 +278,14 @@ func (m Model) View() string {
 		return style.Render("vectro is feeling cramped, make your terminal bigger!")
 	}
 
+	if m.palette.visible {
+		// palette covers everything but the status bar
+		style := internal.NewBox(min(boxMain.GetWidth(), 72), min(boxMain.GetHeight(), 20)).Apply(internal.PaneStyle)
+		str := RenderPane(style, "commands", m.paletteView(style))
+		str = lipgloss.Place(boxMain.GetWidth(), boxMain.GetHeight(), lipgloss.Center, lipgloss.Center, str)
+		return lipgloss.JoinVertical(0, str, box4.Render(internal.StatusStyle, m.status(box4.Apply(internal.StatusStyle))))
+	}
+
 	//
 	// boxes => styles
 	//
@@ -219,6 +294,7 @@ func (m Model) View() string {
 	style2 := box2.Apply(internal.PaneStyle)
 	style3 := box3.Apply(internal.PaneStyle)
 	style4 := box4.Apply(internal.StatusStyle)
+	style5 := box5.Apply(internal.PaneStyle)
 
 	//
 	// render
@@ -233,6 +309,7 @@ func (m Model) View() string {
 	}
 	str3 := RenderPane(style3, "keys", m.help(style3))
 	str4 := style4.Render(m.status(style4))
+	str5 := RenderPane(style5, "registers", m.registers(style5))
 
 	var left string
 	if str2 != "" {
@@ -240,7 +317,11 @@ func (m Model) View() string {
 	} else {
 		left = str1
 	}
-	return lipgloss.JoinVertical(0, lipgloss.JoinHorizontal(0, left, str3), str4)
+	right := str3
+	if str5 != "" {
+		right = lipgloss.JoinVertical(0, str5, str3)
+	}
+	return lipgloss.JoinVertical(0, lipgloss.JoinHorizontal(0, left, right), str4)
 }
 
 // handle vhs stuff
@@ -274,10 +355,72 @@ func (m *Model) vhsUpdate(msg tea.KeyMsg) bool {
 	return true
 }
 
+// does the input want this key? like the "x" in "0x", or enter/backspace. In
+// HEX mode a-f start a number like digits do, so "ff" can be typed without
+// the 0x, and those keys don't run their commands
+func (m *Model) inputAccepts(key string) bool {
+	if !m.inputVisible {
+		return m.c.GetRadix() == internal.Hex && len(key) == 1 && key >= "a" && key <= "f"
+	}
+	if key == "enter" || key == "backspace" {
+		return true
+	}
+	s, radix := m.input.Value(), m.c.GetRadix()
+	if !internal.IsPartialValue(s+key, radix) {
+		return false
+	}
+	// "5m" could be a duration, but if m is bound (to STO) it runs the command
+	// like it always has. Unbound units like the "d" in "5d" are fine, and so is
+	// the "m" in "1h30m"
+	if _, bound := m.keys.Lookup(key); bound && internal.IsPartialNum(s, radix) && !internal.IsPartialNum(s+key, radix) && internal.IsPartialDuration(s+key) {
+		return false
+	}
+	return true
+}
+
+// the input is prompting for a command argument (or an infix expression)
+func (m *Model) onPendingKey(msg tea.KeyMsg) (tea.Cmd, error) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "enter":
+		name, arg := m.pending, m.input.Value()
+		m.closePrompt()
+		if name == actionInfix {
+			return cmd, m.c.RunInfix(arg)
+		}
+		if name == pendingEdit {
+			return cmd, m.editLevel(arg)
+		}
+		if err := m.c.RunArg(name, arg); err != nil {
+			return cmd, fmt.Errorf("%s: %s", name, err.Error())
+		}
+	case "esc":
+		m.closePrompt()
+	default:
+		m.input, cmd = m.input.Update(msg)
+	}
+	return cmd, nil
+}
+
+// show the input, prompting for a command argument
+func (m *Model) openPrompt(name, placeholder string) {
+	m.pending = name
+	m.inputVisible = true
+	m.input.Reset()
+	m.input.Placeholder = placeholder
+}
+
+func (m *Model) closePrompt() {
+	m.pending = ""
+	m.inputVisible = false
+	m.input.Reset()
+	m.input.Placeholder = InputPlaceholder
+}
+
 // handle enter key (or the programmatic equivalent)
 func (m *Model) enter(explicit bool) error {
 	if m.input.Value() != "" {
-		val, err := decimal.NewFromString(m.input.Value())
+		val, err := m.c.Parse(m.input.Value())
 		if err != nil {
 			return errors.New("invalid number")
 		}
@@ -306,18 +449,47 @@ func (m *Model) inputNeg() error {
 	return nil
 }
 
-func (m *Model) paste(str string) {
-	re := regexp.MustCompile(`[^\d.+-]`)
-	paste := re.ReplaceAllString(str, "")
-	if paste != "" {
-		if m.inputVisible {
-			m.input.SetValue(m.input.Value() + paste)
-		} else {
+// paste a number into the input, or a bunch of numbers (and operators) onto
+// the stack as a single undo step
+func (m *Model) paste(str string) error {
+	if m.palette.visible {
+		m.palette.input.SetValue(m.palette.input.Value() + strings.TrimSpace(str))
+		m.palette.input.CursorEnd()
+		m.filterPalette()
+		return nil
+	}
+	if m.pending != "" {
+		m.input.SetValue(m.input.Value() + strings.TrimSpace(str))
+		m.input.CursorEnd()
+		return nil
+	}
+
+	tokens := m.c.ParsePaste(str)
+	if len(tokens) == 1 {
+		if _, err := m.c.Parse(m.input.Value() + tokens[0]); err == nil {
 			m.inputVisible = true
-			m.input.SetValue(paste)
+			m.input.SetValue(m.input.Value() + tokens[0])
+			m.input.CursorEnd()
+			return nil
+		}
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	// if the paste fails the stack is rolled back, so put the input back too
+	input, inputVisible := m.input.Value(), m.inputVisible
+	err := m.c.Batch(func() error {
+		if err := m.enter(false); err != nil {
+			return err
 		}
+		return m.c.RunTokens(tokens)
+	})
+	if err != nil {
+		m.inputVisible = inputVisible
+		m.input.SetValue(input)
 		m.input.CursorEnd()
 	}
+	return err
 }
 
 func (m *Model) run(name string) error {
@@ -332,19 +504,31 @@ func (m *Model) run(name string) error {
 			m.input.Reset()
 			return nil
 		}
+		if name == internal.REDO {
+			// discard the input, it would be lost anyway
+			m.inputVisible = false
+			m.input.Reset()
+		}
 		if err := m.enter(false); err != nil {
 			return err
 		}
 	}
+	if command := internal.CommandsByName[name]; command.Arg != "" {
+		m.openPrompt(command.Name, command.Arg+"...")
+		return nil
+	}
 	if err := m.c.Run(name); err != nil {
 		return fmt.Errorf("%s: %s", name, err.Error())
 	}
-	if name == internal.YANK {
+	if name == internal.YANK || name == internal.YANKD {
 		m.say = "yanked to clipboard"
 	}
 	if name == internal.UNDO {
 		m.say = "undo"
 	}
+	if name == internal.REDO {
+		m.say = "redo"
+	}
 
 	return nil
 }
@@ -370,9 +554,18 @@ func (m Model) title() string {
 }
 
 func (m Model) stack(style lipgloss.Style) string {
-	stack := lo.Map(m.c.GetDisplay(), func(str string, ii int) string {
-		array := strings.Split(str, ":")
-		return internal.IndexStyle.Render(array[0]+":") + internal.GradientStyles[ii].Render(array[1])
+	// scrolled by the browser?
+	top := lo.Ternary(m.browser.visible, m.browser.top, internal.StackSize)
+	stack := lo.Map(m.c.GetDisplayAt(top), func(str string, ii int) string {
+		array := strings.SplitN(str, ":", 2)
+		line := internal.IndexStyle.Render(array[0]+":") + internal.GradientStyles[ii].Render(array[1])
+		if m.browser.visible && top-ii == m.browser.level {
+			line = internal.StackSelectedStyle.Render(str)
+		}
+		if v, ok := m.c.Level(top - ii); ok {
+			line += internal.Swatch(v)
+		}
+		return line
 	})
 	if m.inputVisible {
 		stack = internal.Push(stack, " "+m.input.View())
@@ -386,28 +579,22 @@ func (m Model) history(style lipgloss.Style) string {
 	return strings.Join(history, "\n")
 }
 
-//go:embed help.txt
-var StaticHelpText string
-
-func (m Model) help(style lipgloss.Style) string {
-	// clip/wrap
-	w := style.GetWidth() - style.GetHorizontalPadding()
-	h := style.GetHeight() - style.GetVerticalPadding()
-	plain := lipgloss.NewStyle().
-		Width(w).
-		Height(h).
-		MaxWidth(w).
-		MaxHeight(h).
-		Render(StaticHelpText)
-	return internal.StyleBetweenStars(plain, internal.HelpKeyStyle)
+func (m Model) registers(style lipgloss.Style) string {
+	registers := m.c.GetRegisters()
+	lines := lo.Map(slices.Sorted(maps.Keys(registers)), func(name string, _ int) string {
+		value := registers[name]
+		return internal.IndexStyle.Render(name+":") + " " + m.c.Format(value) + internal.Swatch(value)
+	})
+	return strings.Join(internal.ClipLines(lines, style), "\n")
 }
 
 func (m Model) status(style lipgloss.Style) string {
 	w := style.GetWidth() - style.GetHorizontalPadding()
+	url := "https://github.com/gurgeous/vectro"
 	if w < 60 {
-		return "vectro"
+		url = "vectro"
 	}
-	return "https://github.com/gurgeous/vectro"
+	return strings.Join(internal.Push(m.c.GetModes(), url), " • ")
 }
 
 //
@@ -422,7 +609,23 @@ var (
 
 func main() {
 	args := ParseArgs(os.Args[1:])
-	p := tea.NewProgram(InitModelWithArgs(args), tea.WithAltScreen())
+	if args.eval {
+		os.Exit(Eval(args, os.Stdin, os.Stdout, os.Stderr))
+	}
+	keys, err := LoadKeymap()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vectro: %s\n", err.Error())
+		os.Exit(1)
+	}
+	theme, err := LoadTheme(args.theme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vectro: %s\n", err.Error())
+		os.Exit(1)
+	}
+	internal.SetTheme(theme)
+	m := InitModelWithArgs(args)
+	m.setKeymap(keys)
+	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		panic(err)
 	}
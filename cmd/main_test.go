@@ -1,14 +1,27 @@
 package main
 
 import (
+	"bytes"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/ansi"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/gurgeous/vectro/internal"
 )
 
+// tests expect "1,234.5", whatever the locale
+func TestMain(m *testing.M) {
+	_ = os.Setenv("LC_ALL", "C")
+	os.Exit(m.Run())
+}
+
 func TestAll(t *testing.T) {
 	m := InitModel()
 
@@ -74,6 +87,165 @@ func TestMainMisc(t *testing.T) {
 	// assert.Equal(t, "456", clip)
 }
 
+func TestUndoRedo(t *testing.T) {
+	m := InitModel()
+	m.c.PushInt(2, 3)
+	m, _ = testUpdate(m, testKeyMsg("*"))
+	m, _ = testUpdate(m, testKeyMsg("z"))
+	assert.Equal(t, "undo", m.say)
+	assert.Equal(t, 3, m.c.PeekInt())
+
+	// redo with the input showing discards the input
+	m, _ = testUpdate(m, testKeyMsg("9"))
+	m, _ = testUpdate(m, testKeyMsg("Z"))
+	assert.Equal(t, "redo", m.say)
+	assert.False(t, m.inputVisible)
+	assert.Equal(t, []string{"6"}, m.c.GetStackString())
+
+	m, _ = testUpdate(m, testKeyMsg("Z"))
+	assert.Equal(t, "REDO: nothing to redo", m.err)
+}
+
+func TestPaste(t *testing.T) {
+	m := InitModel()
+	paste := func(s string) {
+		m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s), Paste: true})
+	}
+
+	// one number goes in the input
+	paste("$1,234.50")
+	assert.True(t, m.inputVisible)
+	assert.Equal(t, "1234.50", m.input.Value())
+	m, _ = testUpdate(m, testKeyMsg("enter"))
+
+	// a column of numbers
+	paste("1,000\n2,000\n")
+	assert.Equal(t, []string{"1234.5", "1000", "2000"}, m.c.GetStackString())
+
+	// operators run, all one undo step
+	paste("3 4 + *")
+	assert.Equal(t, []string{"1234.5", "1000", "14000"}, m.c.GetStackString())
+	m, _ = testUpdate(m, testKeyMsg("z"))
+	assert.Equal(t, []string{"1234.5", "1000", "2000"}, m.c.GetStackString())
+
+	// the input is entered first
+	m, _ = testUpdate(m, testKeyMsg("5"))
+	paste("1 2")
+	assert.Equal(t, []string{"1234.5", "1000", "2000", "5", "1", "2"}, m.c.GetStackString())
+
+	// errors roll back, and keep the input
+	m.c.Clear()
+	m, _ = testUpdate(m, testKeyMsg("7"))
+	paste("1 2 bogus")
+	assert.Equal(t, "bogus: unknown command", m.err)
+	assert.True(t, m.c.Empty())
+	assert.True(t, m.inputVisible)
+	assert.Equal(t, "7", m.input.Value())
+}
+
+func TestInfix(t *testing.T) {
+	m := InitModel()
+
+	// implicit enter, then the prompt
+	for _, key := range []string{"2", "="} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	assert.Equal(t, actionInfix, m.pending)
+	for _, r := range "(1 + sqrt(5)) / 2" {
+		m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Empty(t, m.err)
+	assert.Equal(t, []string{"2", "1.6180339888"}, m.c.GetStackString())
+	assert.Equal(t, []string{"(1 + sqrt(5)) / 2 = 1.6180339888"}, m.c.GetHistory())
+
+	// errors
+	m, _ = testUpdate(m, testKeyMsg("'"))
+	m.input.SetValue("1 +")
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "missing operand", m.err)
+	assert.Empty(t, m.pending)
+}
+
+func TestBrowse(t *testing.T) {
+	m := InitModel()
+	m.width, m.height = 80, 40
+	up := tea.KeyMsg{Type: tea.KeyUp}
+
+	// nothing to browse
+	m, _ = testUpdate(m, up)
+	assert.False(t, m.browser.visible)
+
+	for ii := range 10 {
+		m.c.PushInt(ii + 1)
+	}
+	m, _ = testUpdate(m, up)
+	assert.True(t, m.browser.visible)
+	assert.Equal(t, 1, m.browser.level)
+
+	// scroll past the visible rows
+	for range 7 {
+		m, _ = testUpdate(m, up)
+	}
+	assert.Equal(t, 8, m.browser.level)
+	assert.Equal(t, 8, m.browser.top)
+	assert.Contains(t, ansi.Strip(m.View()), "8: 3")
+	assert.NotContains(t, ansi.Strip(m.View()), "1: 10")
+	for range 20 {
+		m, _ = testUpdate(m, up)
+	}
+	assert.Equal(t, 10, m.browser.level)
+
+	// delete, move, copy to top
+	m, _ = testUpdate(m, testKeyMsg("d"))
+	assert.Equal(t, []string{"2", "3", "4", "5", "6", "7", "8", "9", "10"}, m.c.GetStackString())
+	assert.Equal(t, 9, m.browser.level)
+	m, _ = testUpdate(m, testKeyMsg("J"))
+	assert.Equal(t, []string{"3", "2", "4", "5", "6", "7", "8", "9", "10"}, m.c.GetStackString())
+	assert.Equal(t, 8, m.browser.level)
+	m, _ = testUpdate(m, testKeyMsg("p"))
+	assert.Equal(t, "2", m.c.PeekValue().String())
+	assert.Equal(t, 9, m.browser.level)
+
+	// edit
+	m, _ = testUpdate(m, testKeyMsg("e"))
+	assert.Equal(t, pendingEdit, m.pending)
+	assert.Equal(t, "2", m.input.Value())
+	m.input.SetValue("42")
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Empty(t, m.err)
+	assert.True(t, m.browser.visible)
+	assert.Equal(t, "42", m.c.GetStackString()[1])
+
+	// down from level 1 is done
+	for range 9 {
+		m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyDown})
+	}
+	assert.False(t, m.browser.visible)
+	assert.Contains(t, ansi.Strip(m.View()), "1: 2")
+
+	// edits are decimal, even in HEX
+	m.c.Clear()
+	m.c.SetRadix(internal.Hex)
+	m.c.PushInt(255)
+	m.c.PushValue(decimal.NewFromFloat(1.5))
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	for _, msg := range []tea.Msg{up, up, testKeyMsg("e"), enter} {
+		m, _ = testUpdate(m, msg)
+	}
+	assert.Empty(t, m.err)
+	assert.Equal(t, []string{"255", "1.5"}, m.c.GetStackString())
+	for _, msg := range []tea.Msg{tea.KeyMsg{Type: tea.KeyDown}, testKeyMsg("e"), enter} {
+		m, _ = testUpdate(m, msg)
+	}
+	assert.Empty(t, m.err)
+	assert.Equal(t, []string{"255", "1.5"}, m.c.GetStackString())
+	m, _ = testUpdate(m, testKeyMsg("e"))
+	m.input.SetValue("1.2.3")
+	m, _ = testUpdate(m, enter)
+	assert.Equal(t, "can't convert 1.2.3 to decimal: too many .s", m.err)
+}
+
 func TestNeg(t *testing.T) {
 	m := InitModel()
 	m, _ = testUpdate(m, testKeyMsg("1"))
@@ -85,6 +257,244 @@ func TestNeg(t *testing.T) {
 	assert.Equal(t, "+1.2", m.input.Value())
 }
 
+func TestDateInput(t *testing.T) {
+	m := InitModel()
+	typing := func(s string) {
+		for _, r := range s {
+			m, _ = testUpdate(m, testKeyMsg(string(r)))
+		}
+	}
+	typing("2026-10-18T14:30")
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	typing("3d4h30m")
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Empty(t, m.err)
+	assert.Equal(t, []string{"2026-10-18T14:30", "3d4h30m"}, m.c.GetStackString())
+
+	// but m after a number is still STO
+	typing("5m")
+	assert.Equal(t, "STO", m.pending)
+}
+
+func TestRadixInput(t *testing.T) {
+	m := InitModel()
+	for _, key := range []string{"0", "x", "1", "f", "enter"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	assert.Equal(t, 31, m.c.PeekInt())
+
+	// x without the 0 is xor
+	for _, key := range []string{"1", "x"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	assert.Equal(t, 30, m.c.PeekInt())
+
+	// in HEX, a-f start a number instead of running their commands
+	m.c.SetRadix(internal.Hex)
+	angle := m.c.GetAngle()
+	for _, key := range []string{"f", "a", "enter"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	assert.Empty(t, m.err)
+	assert.Equal(t, 250, m.c.PeekInt())
+	assert.Equal(t, angle, m.c.GetAngle())
+}
+
+func TestColorInput(t *testing.T) {
+	m := InitModel()
+	for _, key := range []string{"#", "f", "0", "0", "enter"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	assert.Equal(t, "#ff0000", m.c.PeekValue().String())
+
+	// tailwind prompts for a name, and "q" doesn't quit
+	m, _ = testUpdate(m, testKeyMsg("t"))
+	assert.Equal(t, "TAILWIND", m.pending)
+	for _, key := range []string{"c", "y", "a", "q", "backspace", "n", "-", "5", "0", "0"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Empty(t, m.pending)
+	assert.Equal(t, "#06b6d4", m.c.PeekValue().String())
+	assert.Equal(t, 2, m.c.Len())
+
+	// bad name
+	m, _ = testUpdate(m, testKeyMsg("t"))
+	m, _ = testUpdate(m, testKeyMsg("x"))
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "TAILWIND: unknown color", m.err)
+
+	// swatches
+	m.width, m.height = 80, 40
+	assert.Contains(t, ansi.Strip(m.View()), "#06b6d4")
+}
+
+func TestRegisters(t *testing.T) {
+	m := InitModel()
+	for _, key := range []string{"7", "m", "r", "a", "t", "e"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Empty(t, m.err)
+	assert.Equal(t, 0, m.c.Len())
+
+	// pane
+	m.width, m.height = 80, 40
+	assert.Contains(t, ansi.Strip(m.View()), "rate: 7")
+	m.width, m.height = 80, 15
+	assert.NotContains(t, ansi.Strip(m.View()), "rate: 7")
+
+	m, _ = testUpdate(m, testKeyMsg("M"))
+	for _, key := range []string{"r", "a", "t", "e"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, 7, m.c.PeekInt())
+}
+
+func TestMacroKeys(t *testing.T) {
+	m := InitModel()
+	m, _ = testUpdate(m, testKeyMsg(";"))
+	assert.Equal(t, internal.DEF, m.pending)
+	m.input.SetValue("sq dup *")
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Empty(t, m.err)
+	assert.NoError(t, m.c.RunArg("BIND", "sq Q"))
+
+	// implicit enter, then run the macro
+	for _, key := range []string{"9", "Q"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	assert.Equal(t, 81, m.c.PeekInt())
+	m.c.Clear()
+	m, _ = testUpdate(m, testKeyMsg("Q"))
+	assert.Equal(t, "sq: DUP: stack is empty", m.err)
+
+	// BIND checks the keymap, not the defaults
+	m.setKeymap(lo.Must(ParseKeymap([]byte("keys:\n  sqrt: Q"))))
+	assert.EqualError(t, m.c.RunArg("BIND", "sq Q"), "key is already used")
+	assert.NoError(t, m.c.RunArg("BIND", "sq @"))
+	m.c.PushInt(3)
+	m, _ = testUpdate(m, testKeyMsg("@"))
+	assert.Equal(t, 9, m.c.PeekInt())
+}
+
+func TestPalette(t *testing.T) {
+	m := InitModel()
+	m.width, m.height = 80, 40
+	for _, key := range []string{"4", ":"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	assert.True(t, m.palette.visible)
+	assert.Len(t, m.palette.matches, len(internal.Commands))
+
+	// search by name or description, "q" doesn't quit
+	for _, key := range []string{"s", "q", "r"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	assert.Equal(t, "SQRT", m.palette.matches[0].name)
+	view := ansi.Strip(m.View())
+	assert.Contains(t, view, "commands")
+	assert.Contains(t, view, "square root")
+
+	// implicit enter, then run
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.palette.visible)
+	assert.Equal(t, 2, m.c.PeekInt())
+
+	// arrows
+	m, _ = testUpdate(m, testKeyMsg(":"))
+	for _, key := range []string{"n", "a", "t", "u", "r", "a", "l"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	assert.Equal(t, "LN", m.palette.matches[0].name)
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyUp})
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, strings.HasPrefix(m.c.PeekValue().String(), "0.693147"))
+
+	// esc closes without running anything
+	m, _ = testUpdate(m, testKeyMsg(":"))
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, m.palette.visible)
+	assert.Equal(t, 1, m.c.Len())
+}
+
+func TestHelp(t *testing.T) {
+	// every command shows up, by key or by name
+	text := helpText(DefaultKeymap())
+	for _, command := range internal.Commands {
+		if command.Key() != "" {
+			assert.Contains(t, text, "**"+command.Key()+"**")
+		} else {
+			assert.Contains(t, text, strings.ToLower(command.Name))
+		}
+	}
+
+	// scroll
+	m := InitModel()
+	m.width, m.height = 80, 30
+	assert.Contains(t, ansi.Strip(m.View()), "rpn calculator")
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyPgDown})
+	assert.Equal(t, HelpPageSize, m.helpScroll)
+	assert.NotContains(t, ansi.Strip(m.View()), "rpn calculator")
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyPgUp})
+	m, _ = testUpdate(m, tea.KeyMsg{Type: tea.KeyPgUp})
+	assert.Equal(t, 0, m.helpScroll)
+}
+
+func TestKeymap(t *testing.T) {
+	km, err := ParseKeymap([]byte("keys:\n  sqrt: [r, \"@\"]\n  radix: alt+r\n  quit: ctrl+c\n"))
+	assert.NoError(t, err)
+	name, _ := km.Lookup("r")
+	assert.Equal(t, "SQRT", name)
+	assert.Equal(t, []string{"alt+r"}, km.Keys("RADIX"))
+	assert.False(t, km.Is("q", actionQuit))
+
+	// presets
+	for preset := range Presets {
+		_, err := ParseKeymap([]byte("preset: " + preset))
+		assert.NoError(t, err, preset)
+	}
+	km, _ = ParseKeymap([]byte("preset: vi"))
+	assert.True(t, km.Is("u", internal.UNDO))
+
+	// errors
+	_, err = ParseKeymap([]byte("keys:\n  sqrt: r\n"))
+	assert.EqualError(t, err, `key "r" is bound to both RADIX and SQRT`)
+	_, err = ParseKeymap([]byte("keys:\n  bogus: r\n"))
+	assert.EqualError(t, err, `unknown command "bogus"`)
+	_, err = ParseKeymap([]byte("preset: emacs"))
+	assert.EqualError(t, err, `unknown preset "emacs", try hp or vi`)
+	_, err = ParseKeymap([]byte("keys:\n  number: \"+\"\n"))
+	assert.EqualError(t, err, `key "+" is bound to both ADD and NUMBER`)
+
+	// in the ui
+	m := InitModel()
+	m.keys = lo.Must(ParseKeymap([]byte("keys:\n  sqrt: r\n  radix: R\n  rot: alt+r")))
+	for _, key := range []string{"9", "r"} {
+		m, _ = testUpdate(m, testKeyMsg(key))
+	}
+	assert.Equal(t, 3, m.c.PeekInt())
+	assert.Contains(t, helpText(m.keys), "**r**   square root")
+}
+
+func TestLoadTheme(t *testing.T) {
+	theme, err := LoadTheme("catppuccin")
+	assert.NoError(t, err)
+	assert.Equal(t, internal.Themes["catppuccin"].Border, theme.Border)
+	_, err = LoadTheme("bogus")
+	assert.EqualError(t, err, `unknown theme "bogus", try catppuccin, default, mono, solarized`)
+
+	path := t.TempDir() + "/mine.yml"
+	assert.NoError(t, os.WriteFile(path, []byte("border: sky-500\n"), 0o600))
+	theme, err = LoadTheme(path)
+	assert.NoError(t, err)
+	assert.Equal(t, internal.Sky500, theme.Border)
+	assert.Equal(t, "default", ParseArgs([]string{}).theme)
+	assert.Equal(t, "mono", ParseArgs([]string{"--theme", "mono"}).theme)
+}
+
 func TestRendering(t *testing.T) {
 	m := InitModel()
 
@@ -123,6 +533,38 @@ func TestRendering(t *testing.T) {
 	assert.Contains(t, view, "cramped")
 }
 
+func TestEval(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	// command line
+	args := ParseArgs([]string{"3", "4", "+", "2", "*"})
+	assert.True(t, args.eval)
+	assert.Equal(t, 0, Eval(args, nil, &stdout, &stderr))
+	assert.Equal(t, "14\n", stdout.String())
+
+	// negative numbers aren't flags
+	for _, s := range []string{"-e", "-q", "-v", "-theme", "-version"} {
+		assert.False(t, isNegative(s), s)
+	}
+	args = ParseArgs([]string{"-q", "-1", "2", "+"})
+	assert.True(t, args.noInit)
+	assert.Equal(t, []string{"-1", "2", "+"}, args.tokens)
+	stdout.Reset()
+	assert.Equal(t, 0, Eval(args, nil, &stdout, &stderr))
+	assert.Equal(t, "1\n", stdout.String())
+
+	// stdin
+	stdout.Reset()
+	assert.Equal(t, 0, Eval(Args{eval: true}, strings.NewReader("1 2\n3 +\n"), &stdout, &stderr))
+	assert.Equal(t, "1\n5\n", stdout.String())
+
+	// error
+	stdout.Reset()
+	assert.Equal(t, 1, Eval(Args{tokens: []string{"1", "/"}}, nil, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "DIV: too few arguments")
+}
+
 //
 // helpers
 //
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+
+	"github.com/gurgeous/vectro/internal"
+)
+
+//
+// The command palette, which fuzzy searches commands (and macros) by name and
+// description. Opened with ":" or ctrl+shift+p. Most terminals can't tell
+// ctrl+shift+p from ctrl+p, so that works too.
+//
+
+// defaults, see Keymap
+var PaletteKeys = []string{":", "ctrl+shift+p", "ctrl+p"}
+
+type palette struct {
+	visible  bool
+	input    textinput.Model
+	matches  []paletteItem
+	selected int
+}
+
+type paletteItem struct {
+	name string
+	key  string
+	desc string
+}
+
+func newPaletteInput() textinput.Model {
+	input := textinput.New()
+	input.Focus()
+	input.Placeholder = "search commands..."
+	input.Prompt = ": "
+	input.Cursor.Style = internal.CursorStyle
+	return input
+}
+
+func (m *Model) openPalette() {
+	m.palette.visible = true
+	m.palette.input.Reset()
+	m.filterPalette()
+}
+
+func (m *Model) closePalette() {
+	m.palette.visible = false
+	m.palette.input.Reset()
+}
+
+func (m *Model) onPaletteKey(msg tea.KeyMsg) (tea.Cmd, error) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "enter":
+		matches, selected := m.palette.matches, m.palette.selected
+		m.closePalette()
+		if len(matches) > 0 {
+			return cmd, m.run(matches[selected].name)
+		}
+	case "esc":
+		m.closePalette()
+	case "up", "shift+tab":
+		m.palette.selected = max(m.palette.selected-1, 0)
+	case "down", "tab":
+		m.palette.selected = min(m.palette.selected+1, max(len(m.palette.matches)-1, 0))
+	default:
+		m.palette.input, cmd = m.palette.input.Update(msg)
+		m.filterPalette()
+	}
+	return cmd, nil
+}
+
+// fuzzy search commands & macros, best first. Names count double.
+func (m *Model) filterPalette() {
+	items := lo.Map(internal.Commands, func(c internal.Command, _ int) paletteItem {
+		return paletteItem{name: c.Name, key: strings.Join(m.keys.Keys(c.Name), " "), desc: c.Desc}
+	})
+	for _, macro := range m.c.GetMacros() {
+		items = append(items, paletteItem{name: macro.Name, key: macro.Key, desc: macro.Program()})
+	}
+	slices.SortStableFunc(items, func(a, b paletteItem) int { return strings.Compare(a.name, b.name) })
+
+	query := m.palette.input.Value()
+	scores := map[string]int{}
+	items = lo.Filter(items, func(item paletteItem, _ int) bool {
+		name, nameOk := internal.FuzzyScore(query, item.name)
+		desc, descOk := internal.FuzzyScore(query, item.desc)
+		scores[item.name] = max(lo.Ternary(nameOk, name*2, -1), lo.Ternary(descOk, desc, -1))
+		return nameOk || descOk
+	})
+	slices.SortStableFunc(items, func(a, b paletteItem) int { return scores[b.name] - scores[a.name] })
+
+	m.palette.matches = items
+	m.palette.selected = 0
+}
+
+func (m Model) paletteView(style lipgloss.Style) string {
+	w := style.GetWidth() - style.GetHorizontalPadding()
+	h := style.GetHeight() - style.GetVerticalPadding()
+
+	// scroll so the selection is visible
+	rows := max(h-2, 1)
+	start := max(m.palette.selected-rows+1, 0)
+	matches := m.palette.matches[start:min(start+rows, len(m.palette.matches))]
+
+	lines := []string{m.palette.input.View(), ""}
+	for ii, item := range matches {
+		line := fmt.Sprintf("%-10s %-6s %s", item.name, item.key, item.desc)
+		line = lipgloss.NewStyle().MaxWidth(w).Render(line)
+		if start+ii == m.palette.selected {
+			line = internal.PaletteSelectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(matches) == 0 {
+		lines = append(lines, internal.HelpStyle.Render("no matches"))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"strings"
 
 	"github.com/adrg/xdg"
 	"github.com/gurgeous/vectro/internal"
@@ -11,9 +12,41 @@ import (
 const statePath = "vectro/state.yml"
 
 type state struct {
-	Version int      `yaml:"version"`
-	Stack   []string `yaml:"stack"`
-	History []string `yaml:"history"`
+	Version   int      `yaml:"version"`
+	Stack     []string `yaml:"stack"`
+	History   []string `yaml:"history"`
+	Angle     string   `yaml:"angle"`
+	Rounding  string   `yaml:"rounding"`
+	Precision int      `yaml:"precision"`
+	// fractions
+	Exact    bool `yaml:"exact"`
+	Decimals bool `yaml:"decimals"`
+	// complex
+	Complex bool `yaml:"complex"`
+	Polar   bool `yaml:"polar"`
+	// finance
+	Begin bool              `yaml:"begin"`
+	TVM   map[string]string `yaml:"tvm"`
+	// statistics
+	Sigma map[string]string `yaml:"sigma"`
+	// registers and macros
+	Registers map[string]string `yaml:"registers"`
+	Macros    []macro           `yaml:"macros"`
+	// programmer mode
+	Radix    string `yaml:"radix"`
+	WordSize int    `yaml:"word_size"`
+	Unsigned bool   `yaml:"unsigned"`
+	// display
+	Display  string `yaml:"display"`
+	Digits   int    `yaml:"digits"`
+	Grouping bool   `yaml:"grouping"`
+}
+
+type macro struct {
+	Name    string `yaml:"name"`
+	Key     string `yaml:"key,omitempty"`
+	Program string `yaml:"program"`
+	Radix   string `yaml:"radix"`
 }
 
 // load calculator state. bail if we get any kind of error
@@ -37,11 +70,71 @@ func Load(c *internal.Calculator) {
 
 	c.SetStackString(state.Stack)
 	c.SetHistory(state.History)
+	c.SetRegistersString(state.Registers)
+	c.SetMacros(internal.MapV(state.Macros, func(m macro) internal.Macro {
+		radix, _ := internal.ParseRadix(m.Radix) // DEC if missing
+		return internal.Macro{Name: m.Name, Key: m.Key, Tokens: strings.Fields(m.Program), Radix: radix}
+	}))
+	if angle, ok := internal.ParseAngleMode(state.Angle); ok {
+		c.SetAngle(angle)
+	}
+	if radix, ok := internal.ParseRadix(state.Radix); ok {
+		c.SetRadix(radix)
+	}
+	if rounding, ok := internal.ParseRoundingMode(state.Rounding); ok {
+		c.SetRounding(rounding)
+	}
+	c.SetPrecision(state.Precision)
+	c.SetExact(state.Exact)
+	c.SetDecimals(state.Decimals)
+	c.SetComplex(state.Complex)
+	c.SetPolar(state.Polar)
+	c.SetBegin(state.Begin)
+	c.SetTVMString(state.TVM)
+	c.SetSigmaString(state.Sigma)
+	c.SetWordSize(state.WordSize)
+	c.SetUnsigned(state.Unsigned)
+	if display, ok := internal.ParseDisplayMode(state.Display); ok {
+		c.SetDisplayMode(display, state.Digits)
+	}
+	c.SetGrouping(state.Grouping)
 }
 
 // save calculator state. bail if we get any kind of error
 func Save(c *internal.Calculator) {
-	state := state{Version: 1, Stack: c.GetStackString(), History: c.GetHistory()}
+	display, digits := c.GetDisplayMode()
+	state := state{
+		Version:   1,
+		Stack:     c.GetStackString(),
+		History:   c.GetHistory(),
+		Angle:     c.GetAngle().String(),
+		Rounding:  c.GetRounding().String(),
+		Precision: c.GetPrecision(),
+		// fractions
+		Exact:    c.GetExact(),
+		Decimals: c.GetDecimals(),
+		// complex
+		Complex: c.GetComplex(),
+		Polar:   c.GetPolar(),
+		// finance
+		Begin: c.GetBegin(),
+		TVM:   c.GetTVMString(),
+		// statistics
+		Sigma: c.GetSigmaString(),
+		// registers and macros
+		Registers: c.GetRegistersString(),
+		Macros: internal.MapV(c.GetMacros(), func(m internal.Macro) macro {
+			return macro{Name: m.Name, Key: m.Key, Program: m.Program(), Radix: m.Radix.String()}
+		}),
+		// programmer mode
+		Radix:    c.GetRadix().String(),
+		WordSize: c.GetWordSize(),
+		Unsigned: c.GetUnsigned(),
+		// display
+		Display:  display.String(),
+		Digits:   digits,
+		Grouping: c.GetGrouping(),
+	}
 	data, err := yaml.Marshal(state)
 	if err != nil {
 		panic(err)
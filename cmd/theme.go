@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/adrg/xdg"
+
+	"github.com/gurgeous/vectro/internal"
+)
+
+// user themes live here, like vectro/themes/mine.yml
+const themesPath = "vectro/themes"
+
+// load a built-in theme, a user theme by name, or a theme file by path
+func LoadTheme(name string) (internal.Theme, error) {
+	if theme, ok := internal.Themes[name]; ok {
+		return theme, nil
+	}
+
+	path := name
+	if !strings.HasSuffix(name, ".yml") && !strings.HasSuffix(name, ".yaml") {
+		var err error
+		path, err = xdg.SearchConfigFile(themesPath + "/" + name + ".yml")
+		if err != nil {
+			builtin := strings.Join(slices.Sorted(maps.Keys(internal.Themes)), ", ")
+			return internal.Theme{}, fmt.Errorf("unknown theme %q, try %s", name, builtin)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return internal.Theme{}, err
+	}
+	theme, err := internal.ParseTheme(data)
+	if err != nil {
+		return internal.Theme{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return theme, nil
+}
@@ -0,0 +1,34 @@
+package internal
+
+import "math/big"
+
+//
+// Arithmetic that works on any kind of Number. Quantities win, then Complex,
+// then Fracs stay exact, otherwise it's plain Nums. Valid fns make sure the
+// combination makes sense, see unitsError.
+//
+
+// op on a real Number, exactly if it's a Frac
+func unary(a Number, num func(Num) Num, rat func(z, x *big.Rat) *big.Rat) Number {
+	if f, ok := a.(Frac); ok {
+		return RatValue(rat(new(big.Rat), f.r))
+	}
+	return num(ToNum(a))
+}
+
+// op on two Numbers. Quantity or Complex if either is, exact if either is a
+// Frac (or exact is set)
+func binary(a, b Number, exact bool, num func(x, y Num) Num, rat func(z, x, y *big.Rat) *big.Rat, cplx func(x, y Complex) Complex, qty func(x, y Quantity) Number) Number {
+	if IsQuantity(a) || IsQuantity(b) {
+		return qty(toQuantity(a), toQuantity(b))
+	}
+	if IsComplex(a) || IsComplex(b) {
+		return cplx(toComplex(a), toComplex(b))
+	}
+	_, fracA := a.(Frac)
+	_, fracB := b.(Frac)
+	if exact || fracA || fracB {
+		return RatValue(rat(new(big.Rat), toRat(a), toRat(b)))
+	}
+	return num(ToNum(a), ToNum(b))
+}
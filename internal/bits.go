@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/shopspring/decimal"
+)
+
+//
+// programmer mode - integers are wrapped into a word, which is signed (two's
+// complement) or unsigned
+//
+
+// wrap x into the current word size
+func (c *Calculator) wrap(x *big.Int) Num {
+	x = new(big.Int).And(x, c.wordMask())
+	if !c.unsigned && x.Bit(c.wordSize-1) == 1 {
+		x.Sub(x, new(big.Int).Lsh(big.NewInt(1), uint(c.wordSize))) //nolint:gosec
+	}
+	return decimal.NewFromBigInt(x, 0)
+}
+
+// run a bitwise op on two integers, then wrap
+func (c *Calculator) bitwise(a, b Num, op func(z, x, y *big.Int) *big.Int) Num {
+	return c.wrap(op(new(big.Int), a.BigInt(), b.BigInt()))
+}
+
+// shift x left (n > 0) or right (n < 0), then wrap
+func (c *Calculator) shift(x Num, n int) Num {
+	if n < 0 {
+		return c.wrap(new(big.Int).Rsh(x.BigInt(), uint(-n)))
+	}
+	return c.wrap(new(big.Int).Lsh(x.BigInt(), uint(n)))
+}
+
+// 2^wordSize - 1
+func (c *Calculator) wordMask() *big.Int {
+	one := big.NewInt(1)
+	return new(big.Int).Sub(new(big.Int).Lsh(one, uint(c.wordSize)), one) //nolint:gosec
+}
+
+// does this integer fit in the current word?
+func (c *Calculator) fits(x Num) bool {
+	if !x.IsInteger() {
+		return false
+	}
+	return c.wrap(x.BigInt()).Equal(x)
+}
+
+// for the status bar, like "i64" or "u8"
+func (c *Calculator) wordString() string {
+	sign := "i"
+	if c.unsigned {
+		sign = "u"
+	}
+	return fmt.Sprintf("%s%d", sign, c.wordSize)
+}
+
+// format x for display using the current radix. Negative integers are shown as
+// two's complement, and anything that doesn't fit in the word is left alone.
+func (c *Calculator) formatRadix(x Num) string {
+	if c.radix == Dec || !c.fits(x) {
+		return c.formatDisplay(x)
+	}
+	bits := new(big.Int).And(x.BigInt(), c.wordMask())
+	return radixPrefixes[c.radix] + bits.Text(c.radix.Base())
+}
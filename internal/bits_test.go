@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		wordSize int
+		unsigned bool
+		input    int64
+		expected int64
+	}{
+		{8, false, 127, 127},
+		{8, false, 128, -128},
+		{8, false, -129, 127},
+		{8, true, -1, 255},
+		{8, true, 256, 0},
+		{64, false, -1, -1},
+	}
+	c := NewCalculator()
+	for _, tc := range tests {
+		c.SetWordSize(tc.wordSize)
+		c.SetUnsigned(tc.unsigned)
+		assert.Equal(t, tc.expected, c.wrap(big.NewInt(tc.input)).IntPart())
+	}
+}
+
+func TestFormat(t *testing.T) {
+	c := NewCalculator()
+	assert.Equal(t, "255", c.Format(decimal.NewFromInt(255)))
+
+	c.SetRadix(Hex)
+	assert.Equal(t, "0xff", c.Format(decimal.NewFromInt(255)))
+	assert.Equal(t, "0xffffffffffffffff", c.Format(decimal.NewFromInt(-1)))
+	assert.Equal(t, "1.5", c.Format(decimal.NewFromFloat(1.5)))
+
+	c.SetRadix(Bin)
+	c.SetWordSize(8)
+	assert.Equal(t, "0b11111110", c.Format(decimal.NewFromInt(-2)))
+	assert.Equal(t, "1000", c.Format(decimal.NewFromInt(1000))) // doesn't fit
+
+	c.SetRadix(Oct)
+	assert.Equal(t, "0o10", c.Format(decimal.NewFromInt(8)))
+	assert.Equal(t, []string{"DEG", "OCT i8"}, c.GetModes())
+}
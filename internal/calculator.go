@@ -3,8 +3,11 @@ package internal
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
+	"strings"
 
+	"github.com/atotto/clipboard"
 	"github.com/samber/lo"
 	"github.com/shopspring/decimal"
 )
@@ -14,55 +17,357 @@ import (
 //
 
 type Calculator struct {
-	stack   []Num
+	stack   []Value
 	history []string
-	undo    [][]Num
+	undo    []snapshot
+	redo    []snapshot
+	angle   AngleMode
+	// programmer mode
+	radix    Radix
+	wordSize int
+	unsigned bool
+	rounding RoundingMode
+	// working precision in digits, see PREC
+	precision int
+	// exact fractions, and whether to show them as decimals. See Frac
+	exact    bool
+	decimals bool
+	// complex results for sqrt/ln of negatives, and polar display. See Complex
+	complex bool
+	polar   bool
+	// TVM values by name, and payments at the start of each period. See tvm
+	tvmValues map[string]Num
+	begin     bool
+	// Σ sums for linear regression, see accumulate
+	sigmaValues map[string]Num
+	// display format, see formatDisplay
+	display   DisplayMode
+	digits    int
+	grouping  bool
+	thousands string
+	point     string
+	// named storage registers, see STO/RCL
+	registers map[string]Value
+	// user-defined macros, and how deeply they are nested while running
+	macros map[string]Macro
+	depth  int
+	// keys in use by the keymap, so BIND can't take them. nil means the
+	// defaults, see CommandsByKey
+	boundKeys map[string]bool
+	// argument for the running command, see Command.Arg
+	arg string
+	// FACTOR, SOLVE and IRR are slow, and valid has to work them out to know
+	// if there's an answer. These save doing it twice, see answer
+	factors   answer[[]Num]
+	solutions answer[Num]
+}
+
+// the last answer from a slow fn, keyed by its inputs
+type answer[T any] struct {
+	key   string
+	value T
+	err   error
+}
+
+func (a *answer[T]) get(key string, fn func() (T, error)) (T, error) {
+	if a.key != key {
+		a.value, a.err = fn()
+		a.key = key
+	}
+	return a.value, a.err
+}
+
+// for undo/redo
+type snapshot struct {
+	stack       []Value
+	history     []string
+	registers   map[string]Value
+	tvmValues   map[string]Num
+	sigmaValues map[string]Num
+	macros      map[string]Macro
 }
 
 func NewCalculator() *Calculator {
-	return &Calculator{}
+	return &Calculator{wordSize: 64, precision: Precision, thousands: ",", point: "."}
 }
 
 //
 // accessors
 //
 
-func (c *Calculator) GetStack() []Num {
+func (c *Calculator) GetStack() []Value {
 	return c.stack
 }
 
 func (c *Calculator) GetStackString() []string {
-	return MapV(c.stack, func(x Num) string { return x.String() })
+	return MapV(c.stack, Value.String)
 }
 
 func (c *Calculator) GetHistory() []string {
 	return c.history
 }
 
-func (c *Calculator) SetStack(stack []Num) {
+func (c *Calculator) SetStack(stack []Value) {
 	c.stack = stack
 }
 
+// values that don't parse are skipped, so a hand-edited state.yml can't stop
+// us from starting
 func (c *Calculator) SetStackString(stack []string) {
-	c.SetStack(MapV(stack, decimal.RequireFromString))
+	c.SetStack(lo.FilterMap(stack, func(s string, _ int) (Value, bool) {
+		v, err := ParseValue(s, Dec)
+		return v, err == nil
+	}))
 }
 
 func (c *Calculator) SetHistory(history []string) {
 	c.history = history
 }
 
-func (c *Calculator) GetUndo() [][]Num {
-	return c.undo
+func (c *Calculator) GetRegisters() map[string]Value {
+	return c.registers
+}
+
+func (c *Calculator) GetRegistersString() map[string]string {
+	return lo.MapValues(c.registers, func(v Value, _ string) string { return v.String() })
+}
+
+// like SetStackString, values that don't parse are skipped
+func (c *Calculator) SetRegistersString(registers map[string]string) {
+	c.registers = map[string]Value{}
+	for name, s := range registers {
+		if v, err := ParseValue(s, Dec); err == nil {
+			c.registers[name] = v
+		}
+	}
+}
+
+func (c *Calculator) storeRegister(name string, v Value) {
+	if c.registers == nil {
+		c.registers = map[string]Value{}
+	}
+	c.registers[name] = v
+}
+
+func (c *Calculator) GetTVMString() map[string]string {
+	return numsString(c.tvmValues)
+}
+
+func (c *Calculator) SetTVMString(values map[string]string) {
+	c.tvmValues = parseNums(values)
+}
+
+func (c *Calculator) GetSigmaString() map[string]string {
+	return numsString(c.sigmaValues)
+}
+
+func (c *Calculator) SetSigmaString(values map[string]string) {
+	c.sigmaValues = parseNums(values)
+}
+
+func numsString(nums map[string]Num) map[string]string {
+	return lo.MapValues(nums, func(x Num, _ string) string { return x.String() })
+}
+
+// like SetRegistersString, values that don't parse are skipped
+func parseNums(values map[string]string) map[string]Num {
+	nums := map[string]Num{}
+	for name, s := range values {
+		if x, err := decimal.NewFromString(s); err == nil {
+			nums[name] = x
+		}
+	}
+	return nums
+}
+
+func (c *Calculator) GetAngle() AngleMode {
+	return c.angle
+}
+
+func (c *Calculator) SetAngle(angle AngleMode) {
+	c.angle = angle
+}
+
+func (c *Calculator) GetRadix() Radix {
+	return c.radix
+}
+
+func (c *Calculator) SetRadix(radix Radix) {
+	c.radix = radix
+}
+
+func (c *Calculator) GetWordSize() int {
+	return c.wordSize
+}
+
+func (c *Calculator) SetWordSize(wordSize int) {
+	if slices.Contains(WordSizes, wordSize) {
+		c.wordSize = wordSize
+	}
+}
+
+func (c *Calculator) GetUnsigned() bool {
+	return c.unsigned
+}
+
+func (c *Calculator) SetUnsigned(unsigned bool) {
+	c.unsigned = unsigned
+}
+
+func (c *Calculator) GetRounding() RoundingMode {
+	return c.rounding
+}
+
+func (c *Calculator) SetRounding(rounding RoundingMode) {
+	c.rounding = rounding
+}
+
+func (c *Calculator) GetPrecision() int {
+	return c.precision
+}
+
+func (c *Calculator) SetPrecision(precision int) {
+	if precision >= MinPrecision && precision <= MaxPrecision {
+		c.precision = precision
+	}
+}
+
+func (c *Calculator) GetExact() bool {
+	return c.exact
+}
+
+func (c *Calculator) SetExact(exact bool) {
+	c.exact = exact
+}
+
+func (c *Calculator) GetDecimals() bool {
+	return c.decimals
+}
+
+func (c *Calculator) SetDecimals(decimals bool) {
+	c.decimals = decimals
+}
+
+func (c *Calculator) GetComplex() bool {
+	return c.complex
+}
+
+func (c *Calculator) SetComplex(complex bool) {
+	c.complex = complex
+}
+
+func (c *Calculator) GetPolar() bool {
+	return c.polar
+}
+
+func (c *Calculator) SetPolar(polar bool) {
+	c.polar = polar
 }
 
-// returns the 8 visible lines of the stack
+func (c *Calculator) GetBegin() bool {
+	return c.begin
+}
+
+func (c *Calculator) SetBegin(begin bool) {
+	c.begin = begin
+}
+
+func (c *Calculator) GetDisplayMode() (DisplayMode, int) {
+	return c.display, c.digits
+}
+
+func (c *Calculator) SetDisplayMode(display DisplayMode, digits int) {
+	if digits >= 0 && digits <= maxDigits {
+		c.display, c.digits = display, digits
+	}
+}
+
+func (c *Calculator) GetGrouping() bool {
+	return c.grouping
+}
+
+func (c *Calculator) SetGrouping(grouping bool) {
+	c.grouping = grouping
+}
+
+// use separators from a locale like "de_DE.UTF-8". The decimal point always
+// applies, thousands separators only with GROUP
+func (c *Calculator) SetLocale(locale string) {
+	c.thousands, c.point = LocaleSeparators(locale)
+}
+
+// keys in use by the keymap, which might not be the defaults
+func (c *Calculator) SetBoundKeys(keys []string) {
+	c.boundKeys = lo.SliceToMap(keys, func(key string) (string, bool) { return key, true })
+}
+
+// modes for the status bar
+func (c *Calculator) GetModes() []string {
+	modes := []string{c.angle.String()}
+	if c.radix != Dec {
+		modes = append(modes, c.radix.String()+" "+c.wordString())
+	} else if c.wordSize != 64 || c.unsigned {
+		modes = append(modes, c.wordString())
+	}
+	if c.rounding != HalfUp {
+		modes = append(modes, c.rounding.String())
+	}
+	if c.precision != Precision {
+		modes = append(modes, fmt.Sprintf("PREC %d", c.precision))
+	}
+	if c.exact {
+		modes = append(modes, "EXACT")
+	}
+	if c.decimals {
+		modes = append(modes, "DECIMALS")
+	}
+	if c.complex {
+		modes = append(modes, "COMPLEX")
+	}
+	if c.polar {
+		modes = append(modes, "POLAR")
+	}
+	if c.begin {
+		modes = append(modes, "BEGIN")
+	}
+	if c.display != Std {
+		modes = append(modes, fmt.Sprintf("%s %d", c.display, c.digits))
+	}
+	if c.grouping {
+		modes = append(modes, "1"+c.thousands+"000")
+	}
+	return modes
+}
+
+// format a value for display, using the current radix for Nums
+func (c *Calculator) Format(v Value) string {
+	switch v := v.(type) {
+	case Num:
+		return c.formatRadix(v)
+	case Frac:
+		if c.decimals {
+			return c.formatRadix(NormalizePrec(v.Num(), c.precision))
+		}
+	case Complex:
+		return c.formatComplex(v)
+	case Quantity:
+		return c.formatPart(v.Value) + " " + v.Unit.String()
+	}
+	return v.String()
+}
+
+// returns the visible lines of the stack
 func (c *Calculator) GetDisplay() []string {
+	return c.GetDisplayAt(StackSize)
+}
+
+// returns StackSize lines of the stack, scrolled so top is the first line
+func (c *Calculator) GetDisplayAt(top int) []string {
 	result := make([]string, StackSize)
 	for ii := range StackSize {
-		var s = fmt.Sprintf("%d: ", StackSize-ii)
-		si := c.Len() - (StackSize - ii)
-		if si >= 0 {
-			s += c.stack[si].String()
+		var s = fmt.Sprintf("%d: ", top-ii)
+		if v, ok := c.Level(top - ii); ok {
+			s += c.Format(v)
 		}
 		result[ii] = s
 	}
@@ -70,15 +375,124 @@ func (c *Calculator) GetDisplay() []string {
 }
 
 //
-// undo
+// stack levels, counting from 1 at the top like the display. These are for
+// the stack browser and each one is an undo step.
 //
 
+// the value at level n, if there is one
+func (c *Calculator) Level(n int) (Value, bool) {
+	if n < 1 || n > c.Len() {
+		return nil, false
+	}
+	return c.stack[c.Len()-n], true
+}
+
+// replace the value at level n
+func (c *Calculator) SetLevel(n int, v Value) error {
+	if _, ok := c.Level(n); !ok {
+		return errors.New("no such level")
+	}
+	c.snapshotForUndo()
+	c.stack[c.Len()-n] = c.normalize(v)
+	return nil
+}
+
+// copy the value at level n to the top
+func (c *Calculator) PickLevel(n int) error {
+	v, ok := c.Level(n)
+	if !ok {
+		return errors.New("no such level")
+	}
+	c.snapshotForUndo()
+	c.PushValue(v)
+	return nil
+}
+
+// copy the value at level n to the clipboard
+func (c *Calculator) YankLevel(n int) error {
+	v, ok := c.Level(n)
+	if !ok {
+		return errors.New("no such level")
+	}
+	_ = clipboard.WriteAll(v.String())
+	return nil
+}
+
+// remove the value at level n
+func (c *Calculator) DeleteLevel(n int) error {
+	if _, ok := c.Level(n); !ok {
+		return errors.New("no such level")
+	}
+	c.snapshotForUndo()
+	c.stack = slices.Delete(c.stack, c.Len()-n, c.Len()-n+1)
+	return nil
+}
+
+// swap the values at levels n and m, to move a value up or down
+func (c *Calculator) SwapLevels(n, m int) error {
+	_, okN := c.Level(n)
+	_, okM := c.Level(m)
+	if !okN || !okM {
+		return errors.New("no such level")
+	}
+	c.snapshotForUndo()
+	ii, jj := c.Len()-n, c.Len()-m
+	c.stack[ii], c.stack[jj] = c.stack[jj], c.stack[ii]
+	return nil
+}
+
+//
+// undo/redo. Any new snapshot clears the redo stack.
+//
+
+func (c *Calculator) snapshot() snapshot {
+	return snapshot{
+		stack:       slices.Clone(c.stack),
+		history:     slices.Clone(c.history),
+		registers:   maps.Clone(c.registers),
+		tvmValues:   maps.Clone(c.tvmValues),
+		sigmaValues: maps.Clone(c.sigmaValues),
+		macros:      maps.Clone(c.macros),
+	}
+}
+
+func (c *Calculator) restore(s snapshot) {
+	c.stack, c.history, c.registers, c.macros = s.stack, s.history, s.registers, s.macros
+	c.tvmValues, c.sigmaValues = s.tvmValues, s.sigmaValues
+}
+
 func (c *Calculator) snapshotForUndo() {
-	c.undo = TruncateStart(Push(c.undo, slices.Clone(c.stack)), UndoSize)
+	c.undo = TruncateStart(Push(c.undo, c.snapshot()), UndoSize)
+	c.redo = nil
 }
 
 func (c *Calculator) Undo() {
-	c.stack, c.undo = Pop(c.undo)
+	var s snapshot
+	s, c.undo = Pop(c.undo)
+	c.redo = Push(c.redo, c.snapshot())
+	c.restore(s)
+}
+
+func (c *Calculator) Redo() {
+	var s snapshot
+	s, c.redo = Pop(c.redo)
+	c.undo = TruncateStart(Push(c.undo, c.snapshot()), UndoSize)
+	c.restore(s)
+}
+
+// Run fn as a single undo step. If fn fails, everything it did is rolled back,
+// modes included. The copy covers the modes, the snapshot covers the maps and
+// slices that fn might change in place.
+func (c *Calculator) Batch(fn func() error) error {
+	saved, before := *c, c.snapshot()
+	if err := fn(); err != nil {
+		*c = saved
+		c.restore(before)
+		return err
+	}
+	c.undo = TruncateStart(Push(saved.undo, before), UndoSize)
+	c.redo = nil
+	return nil
 }
 
 //
@@ -94,11 +508,11 @@ func (c *Calculator) History() []string {
 	return c.history
 }
 
-func (c *Calculator) Enter(value Num, explicit bool) {
+func (c *Calculator) Enter(value Value, explicit bool) {
 	if explicit {
 		c.snapshotForUndo()
 	}
-	c.Push(value)
+	c.PushValue(value)
 }
 
 //
@@ -114,20 +528,66 @@ func (c *Calculator) Len() int {
 }
 
 func (c *Calculator) Push(values ...Num) {
-	var normalized = MapV(values, Normalize)
-	c.stack = TruncateStart(Push(c.stack, normalized...), MaxArraySize)
+	c.PushValue(MapV(values, func(x Num) Value { return x })...)
 }
 
 func (c *Calculator) Pop() Num {
-	var x Num
-	x, c.stack = Pop(c.stack)
-	return x
+	return ToNum(c.PopValue())
 }
 
 func (c *Calculator) Peek() Num {
+	return ToNum(c.PeekValue())
+}
+
+func (c *Calculator) PushValue(values ...Value) {
+	c.stack = TruncateStart(Push(c.stack, MapV(values, c.normalize)...), MaxArraySize)
+}
+
+// round off to our precision
+func (c *Calculator) normalize(v Value) Value {
+	switch v := v.(type) {
+	case Num:
+		return NormalizePrec(v, c.precision)
+	case Complex:
+		return ComplexValue(NormalizePrec(v.Re, c.precision), NormalizePrec(v.Im, c.precision))
+	case Quantity:
+		return Quantity{Value: NormalizePrec(v.Value, c.precision), Unit: v.Unit}
+	}
+	return v
+}
+
+func (c *Calculator) PopValue() Value {
+	var v Value
+	v, c.stack = Pop(c.stack)
+	return v
+}
+
+func (c *Calculator) PeekValue() Value {
 	return lo.Must(lo.Last(c.stack))
 }
 
+// the values for a list fn like SUM, the whole stack or the n values below
+// the count
+func (c *Calculator) listValues(counted bool) []Value {
+	if !counted {
+		return c.stack
+	}
+	n := int(c.Peek().IntPart())
+	return c.stack[c.Len()-1-n : c.Len()-1]
+}
+
+// pop the values (and the count) for a list fn
+func (c *Calculator) popList(counted bool) []Num {
+	values := MapV(c.listValues(counted), ToNum)
+	c.stack = c.stack[:c.Len()-len(values)-lo.Ternary(counted, 1, 0)]
+	return values
+}
+
+// peek at the nth value from the top, 0 is the top
+func (c *Calculator) PeekN(n int) Value {
+	return c.stack[c.Len()-1-n]
+}
+
 //
 // these are handy
 //
@@ -156,6 +616,34 @@ func (c *Calculator) PeekFloat() float64 {
 	return c.Peek().InexactFloat64()
 }
 
+//
+// angles
+//
+
+// convert from the current angle mode to radians
+func (c *Calculator) toRadians(x Num) Num {
+	switch c.angle {
+	case Deg:
+		return Div(x.Mul(Pi(c.precision)), decimal.NewFromInt(180), c.precision)
+	case Grad:
+		return Div(x.Mul(Pi(c.precision)), decimal.NewFromInt(200), c.precision)
+	case Rad:
+	}
+	return x
+}
+
+// convert from radians to the current angle mode
+func (c *Calculator) fromRadians(x Num) Num {
+	switch c.angle {
+	case Deg:
+		return Div(x.Mul(decimal.NewFromInt(180)), Pi(c.precision), c.precision)
+	case Grad:
+		return Div(x.Mul(decimal.NewFromInt(200)), Pi(c.precision), c.precision)
+	case Rad:
+	}
+	return x
+}
+
 //
 // history operations
 //
@@ -169,33 +657,65 @@ func (c *Calculator) AddHistory(s string) {
 //
 
 func (c *Calculator) Run(name string) error {
+	return c.RunArg(name, "")
+}
+
+// Run a command that needs an argument, like "TAILWIND blue-400". See
+// Command.Arg.
+func (c *Calculator) RunArg(name string, arg string) error {
 	cmd, ok := CommandsByName[name]
 	if !ok {
+		if _, ok := c.macros[name]; ok {
+			return c.RunMacro(name)
+		}
 		panic("unknown command " + name)
 	}
+	if cmd.Arg != "" && arg == "" {
+		return errors.New("missing " + cmd.Arg)
+	}
+	c.arg = arg
+	defer func() { c.arg = "" }()
 
 	//
-	// do we have enough on the stack to run this command?
+	// do we have enough on the stack to run this command? And are they numbers,
+	// if the fn wants Nums (or Numbers)?
 	//
 
-	switch cmd.fn.(type) {
-	case func(*Calculator), func(*Calculator) Num:
-		// nop
-	case func(*Calculator, Num), func(*Calculator, Num) Num:
-		if c.Len() < 1 {
-			return errors.New("stack is empty")
-		}
-	case func(*Calculator, Num, Num), func(*Calculator, Num, Num) Num:
-		if c.Len() < 2 {
+	n, check := arity(cmd)
+	switch {
+	case n == 1 && c.Len() < 1:
+		return errors.New("stack is empty")
+	case c.Len() < n:
+		return errors.New("too few arguments")
+	}
+	if check != nil {
+		for ii := range n {
+			if !check(c.PeekN(ii)) {
+				return errors.New("not a number")
+			}
+		}
+	}
+	if cmd.counted {
+		count := c.Peek()
+		if !count.IsInteger() || count.IsNegative() {
+			return errors.New("bad count")
+		}
+		if count.GreaterThan(decimal.NewFromInt(int64(c.Len() - 1))) {
 			return errors.New("too few arguments")
 		}
-	default:
-		panic("unknown command fn sig " + name)
+	}
+	if _, ok := cmd.fn.(func(*Calculator, []Num) Num); ok {
+		values := c.listValues(cmd.counted)
+		switch {
+		case len(values) == 0:
+			return errors.New("too few arguments")
+		case !lo.EveryBy(values, IsNum):
+			return errors.New("not a number")
+		}
 	}
 
 	//
-	// is the cmd ready to go? for example, can't ADD without at least two
-	// values on the stack
+	// is the cmd ready to go? for example, can't DIV by zero
 	//
 
 	if cmd.valid != nil {
@@ -203,7 +723,7 @@ func (c *Calculator) Run(name string) error {
 			return err
 		}
 	}
-	if cmd.Name != "UNDO" {
+	if cmd.Name != UNDO && cmd.Name != REDO {
 		c.snapshotForUndo()
 	}
 
@@ -218,26 +738,88 @@ func (c *Calculator) Run(name string) error {
 		fn(c)
 	case func(*Calculator, Num):
 		fn(c, c.Pop())
+	case func(*Calculator, Value):
+		fn(c, c.PopValue())
 	case func(*Calculator) Num:
 		c.Push(fn(c))
+	case func(*Calculator) Value:
+		c.PushValue(fn(c))
 	case func(*Calculator, Num) Num:
 		a := c.Pop()
 		c.Push(fn(c, a))
 		if cmd.fmt != "" {
 			history = fmt.Sprintf(cmd.fmt, a, c.Peek())
 		}
+	case func(*Calculator, Number) Number:
+		a := c.PopValue()
+		c.PushValue(fn(c, a.(Number)))
+		if cmd.fmt != "" {
+			history = fmt.Sprintf(cmd.fmt, a, c.PeekValue())
+		}
+	case func(*Calculator, Value) Value:
+		a := c.PopValue()
+		c.PushValue(fn(c, a))
+		if cmd.fmt != "" {
+			history = fmt.Sprintf(cmd.fmt, a, c.PeekValue())
+		}
+	case func(*Calculator, []Num) Num:
+		values := c.popList(cmd.counted)
+		c.Push(fn(c, values))
+		if cmd.fmt != "" {
+			history = fmt.Sprintf(cmd.fmt, strings.Join(MapV(values, Num.String), ", "), c.Peek())
+		}
+	case func(*Calculator, Num) []Num:
+		a := c.Pop()
+		results := fn(c, a)
+		c.Push(results...)
+		if cmd.fmt != "" {
+			history = fmt.Sprintf(cmd.fmt, a, strings.Join(MapV(results, Num.String), ", "))
+		}
 	case func(*Calculator, Num, Num):
 		b, a := c.Pop(), c.Pop()
 		fn(c, a, b)
 		if cmd.fmt != "" {
 			history = fmt.Sprintf(cmd.fmt, a, b)
 		}
+	case func(*Calculator, Value, Value):
+		b, a := c.PopValue(), c.PopValue()
+		fn(c, a, b)
+		if cmd.fmt != "" {
+			history = fmt.Sprintf(cmd.fmt, a, b)
+		}
+	case func(*Calculator, Value, Value, Value):
+		z, y, x := c.PopValue(), c.PopValue(), c.PopValue()
+		fn(c, x, y, z)
 	case func(*Calculator, Num, Num) Num:
 		b, a := c.Pop(), c.Pop()
 		c.Push(fn(c, a, b))
 		if cmd.fmt != "" {
 			history = fmt.Sprintf(cmd.fmt, a, b, c.Peek())
 		}
+	case func(*Calculator, Number, Number) Number:
+		b, a := c.PopValue(), c.PopValue()
+		c.PushValue(fn(c, a.(Number), b.(Number)))
+		if cmd.fmt != "" {
+			history = fmt.Sprintf(cmd.fmt, a, b, c.PeekValue())
+		}
+	case func(*Calculator, Value, Value) Value:
+		b, a := c.PopValue(), c.PopValue()
+		c.PushValue(fn(c, a, b))
+		if cmd.fmt != "" {
+			history = fmt.Sprintf(cmd.fmt, a, b, c.PeekValue())
+		}
+	case func(*Calculator, Num, Num) Value:
+		b, a := c.Pop(), c.Pop()
+		c.PushValue(fn(c, a, b))
+		if cmd.fmt != "" {
+			history = fmt.Sprintf(cmd.fmt, a, b, c.PeekValue())
+		}
+	case func(*Calculator, Num, Num, Num) Value:
+		z, y, x := c.Pop(), c.Pop(), c.Pop()
+		c.PushValue(fn(c, x, y, z))
+		if cmd.fmt != "" {
+			history = fmt.Sprintf(cmd.fmt, x, y, z, c.PeekValue())
+		}
 	default:
 		panic("unknown command fn sig " + name)
 	}
@@ -252,3 +834,87 @@ func (c *Calculator) Run(name string) error {
 
 	return nil
 }
+
+// how many values does this command pop, and what do they have to be? Nums
+// take real numbers, Numbers take any number and Values take anything. Counted
+// commands pop a count, and then need that many more values.
+func arity(cmd Command) (int, func(Value) bool) {
+	switch cmd.fn.(type) {
+	case func(*Calculator), func(*Calculator) Num, func(*Calculator) Value:
+		return 0, nil
+	case func(*Calculator, Num), func(*Calculator, Num) Num, func(*Calculator, Num) []Num:
+		return 1, IsNum
+	case func(*Calculator, []Num) Num:
+		// the top is a value or the count, RunArg checks the rest
+		return 1, IsNum
+	case func(*Calculator, Number) Number:
+		return 1, IsNumber
+	case func(*Calculator, Value), func(*Calculator, Value) Value:
+		return 1, nil
+	case func(*Calculator, Num, Num), func(*Calculator, Num, Num) Num, func(*Calculator, Num, Num) Value:
+		return 2, IsNum
+	case func(*Calculator, Number, Number) Number:
+		return 2, IsNumber
+	case func(*Calculator, Value, Value) Value:
+		// arithmetic, which also works on dates, see dateError
+		return 2, func(v Value) bool { return IsNumber(v) || IsTime(v) }
+	case func(*Calculator, Value, Value):
+		return 2, nil
+	case func(*Calculator, Num, Num, Num) Value:
+		return 3, IsNum
+	case func(*Calculator, Value, Value, Value):
+		return 3, nil
+	}
+	panic("unknown command fn sig " + cmd.Name)
+}
+
+//
+// Run a list of tokens, like "3 4 + 2 *". Commands are looked up first (so
+// "add" isn't a hex number), then macros, everything else is pushed as a
+// value. Commands that need an argument take the next token, like "tailwind
+// blue-400". Macros are defined with ": name ... ;".
+//
+
+func (c *Calculator) RunTokens(tokens []string) error {
+	return c.runTokens(tokens, c.Parse)
+}
+
+// run tokens, parsing values with parse
+func (c *Calculator) runTokens(tokens []string, parse func(string) (Value, error)) error {
+	for len(tokens) > 0 {
+		var token, arg string
+		token, tokens = Shift(tokens)
+		if cmd, ok := LookupCommand(token); ok {
+			if cmd.Arg != "" && len(tokens) > 0 {
+				arg, tokens = Shift(tokens)
+			}
+			if err := c.RunArg(cmd.Name, arg); err != nil {
+				return fmt.Errorf("%s: %s", cmd.Name, err.Error())
+			}
+			continue
+		}
+		if token == ":" {
+			end := slices.Index(tokens, ";")
+			if end == -1 {
+				return errors.New(": missing ;")
+			}
+			if err := c.RunArg(DEF, strings.Join(tokens[:end], " ")); err != nil {
+				return fmt.Errorf("%s: %s", DEF, err.Error())
+			}
+			tokens = tokens[end+1:]
+			continue
+		}
+		if _, ok := c.macros[token]; ok {
+			if err := c.RunMacro(token); err != nil {
+				return err
+			}
+			continue
+		}
+		val, err := parse(token)
+		if err != nil {
+			return fmt.Errorf("%s: unknown command", token)
+		}
+		c.PushValue(val)
+	}
+	return nil
+}
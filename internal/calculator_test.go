@@ -106,6 +106,95 @@ func TestCalculatorRunHistory(t *testing.T) {
 	c.PushInt(123, 456)
 	c.Run("ADD")
 	assert.Equal(t, "123 + 456 = 579", c.History()[0])
+	c.PushInt(12)
+	c.Run("FACTOR")
+	assert.Equal(t, "factor(12) = 2, 2, 3", c.History()[1])
+}
+
+func TestCalculatorRunTokens(t *testing.T) {
+	c := NewCalculator()
+	assert.NoError(t, c.RunTokens([]string{"3", "4", "+", "2", "mul", "-1.5"}))
+	assert.Equal(t, []string{"14", "-1.5"}, c.GetStackString())
+
+	// values and arguments
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"#f00", "tailwind", "blue-400", "swap"}))
+	assert.Equal(t, []string{"#60a5fa", "#ff0000"}, c.GetStackString())
+
+	// errors
+	assert.ErrorContains(t, c.RunTokens([]string{"bogus"}), "unknown command")
+	assert.ErrorContains(t, c.RunTokens([]string{"tailwind"}), "missing tailwind color")
+	c.Clear()
+	assert.ErrorContains(t, c.RunTokens([]string{"1", "+"}), "ADD: too few arguments")
+}
+
+func TestCalculatorStackString(t *testing.T) {
+	c := NewCalculator()
+	stack := []string{"1.5", "#ff0000", "hsl(0 100% 50%)"}
+	c.SetStackString(stack)
+	assert.Equal(t, stack, c.GetStackString())
+	assert.Equal(t, "#ff0000", c.PeekN(1).String())
+
+	// bad values are skipped
+	c.SetStackString([]string{"1", "bogus", "2"})
+	assert.Equal(t, []string{"1", "2"}, c.GetStackString())
+	c.SetRegistersString(map[string]string{"a": "1", "b": "bogus"})
+	assert.Equal(t, map[string]string{"a": "1"}, c.GetRegistersString())
+}
+
+func TestNumber(t *testing.T) {
+	c := NewCalculator()
+	tests := []struct {
+		input  string
+		number bool
+	}{
+		{"1.5", true},
+		{"1/3", true},
+		{"3+4i", true},
+		{"5_m", true},
+		{"2026-10-18", false},
+		{"1d", false},
+		{"#ff0000", false},
+	}
+	for _, tc := range tests {
+		v, err := c.Parse(tc.input)
+		assert.NoError(t, err, tc.input)
+		_, ok := v.(Number)
+		assert.Equal(t, tc.number, ok, tc.input)
+	}
+}
+
+func TestCalculatorLevels(t *testing.T) {
+	c := NewCalculator()
+	c.SetStackString([]string{"1", "2", "3", "4"})
+
+	v, ok := c.Level(1)
+	assert.True(t, ok)
+	assert.Equal(t, "4", v.String())
+	_, ok = c.Level(5)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.SetLevel(3, decimal.NewFromFloat(1.00000001)))
+	assert.Equal(t, []string{"1", "1", "3", "4"}, c.GetStackString())
+	assert.NoError(t, c.SwapLevels(2, 3))
+	assert.Equal(t, []string{"1", "3", "1", "4"}, c.GetStackString())
+	assert.NoError(t, c.PickLevel(4))
+	assert.Equal(t, []string{"1", "3", "1", "4", "1"}, c.GetStackString())
+	assert.NoError(t, c.DeleteLevel(2))
+	assert.Equal(t, []string{"1", "3", "1", "1"}, c.GetStackString())
+
+	// each one is an undo step
+	c.Undo()
+	assert.Equal(t, []string{"1", "3", "1", "4", "1"}, c.GetStackString())
+
+	// errors
+	assert.EqualError(t, c.SetLevel(0, One), "no such level")
+	assert.EqualError(t, c.DeleteLevel(6), "no such level")
+	assert.EqualError(t, c.SwapLevels(5, 6), "no such level")
+	assert.EqualError(t, c.PickLevel(6), "no such level")
+
+	// scrolled display
+	assert.Equal(t, []string{"8: ", "7: ", "6: ", "5: 1", "4: 3", "3: 1"}, c.GetDisplayAt(8))
 }
 
 func TestEnter(t *testing.T) {
@@ -133,3 +222,35 @@ func TestUndo(t *testing.T) {
 	assert.Equal(t, 1, c.Len())
 	assert.Equal(t, 123, c.PeekInt())
 }
+
+func TestRedo(t *testing.T) {
+	c := NewCalculator()
+	c.PushInt(1, 2)
+	assert.NoError(t, c.Run("ADD"))
+	assert.Equal(t, []string{"1 + 2 = 3"}, c.History())
+
+	// undo takes back the history too
+	assert.NoError(t, c.Run("UNDO"))
+	assert.Equal(t, []string{"1", "2"}, c.GetStackString())
+	assert.Empty(t, c.History())
+
+	// redo
+	assert.NoError(t, c.Run("REDO"))
+	assert.Equal(t, []string{"3"}, c.GetStackString())
+	assert.Equal(t, []string{"1 + 2 = 3"}, c.History())
+	assert.EqualError(t, c.Run("REDO"), "nothing to redo")
+
+	// undo/redo/undo
+	assert.NoError(t, c.Run("UNDO"))
+	assert.NoError(t, c.Run("REDO"))
+	assert.NoError(t, c.Run("UNDO"))
+	assert.Equal(t, []string{"1", "2"}, c.GetStackString())
+
+	// a new command clears redo
+	assert.NoError(t, c.Run("MUL"))
+	assert.EqualError(t, c.Run("REDO"), "nothing to redo")
+	c.Enter(decimal.NewFromInt(5), true)
+	assert.NoError(t, c.Run("UNDO"))
+	assert.NoError(t, c.Run("REDO"))
+	assert.Equal(t, []string{"2", "5"}, c.GetStackString())
+}
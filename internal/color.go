@@ -0,0 +1,262 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+//
+// Color values. These are stored as srgb and remember which space they should
+// be displayed in.
+//
+
+type ColorSpace int
+
+const (
+	ColorHex ColorSpace = iota
+	ColorRGB
+	ColorHSL
+	ColorOKLCH
+)
+
+type Color struct {
+	// srgb, 0..1
+	R, G, B float64
+	Space   ColorSpace
+}
+
+var (
+	colorHexRe     = regexp.MustCompile(`^#([\da-fA-F]{3}|[\da-fA-F]{6})$`)
+	colorFuncRe    = regexp.MustCompile(`^(rgb|hsl|oklch)\((.*)\)$`)
+	partialColorRe = regexp.MustCompile(`^#[\da-fA-F]{0,6}$`)
+)
+
+func NewColorRGB(r, g, b float64) Color {
+	return Color{R: clamp01(r / 255), G: clamp01(g / 255), B: clamp01(b / 255), Space: ColorRGB}
+}
+
+// h is degrees, s and l are 0..100
+func NewColorHSL(h, s, l float64) Color {
+	h, s, l = math.Mod(math.Mod(h, 360)+360, 360)/360, s/100, l/100
+	if s == 0 {
+		return Color{R: l, G: l, B: l, Space: ColorHSL}
+	}
+	q := l + s - l*s
+	if l < 0.5 {
+		q = l * (1 + s)
+	}
+	p := 2*l - q
+	return Color{R: hue2rgb(p, q, h+1.0/3), G: hue2rgb(p, q, h), B: hue2rgb(p, q, h-1.0/3), Space: ColorHSL}
+}
+
+// l is 0..100, c is chroma (usually 0..0.4), h is degrees. Out of gamut colors
+// are clamped.
+func NewColorOKLCH(l, c, h float64) Color {
+	l /= 100
+	a, b := c*math.Cos(h*math.Pi/180), c*math.Sin(h*math.Pi/180)
+
+	l_ := l + 0.3963377774*a + 0.2158037573*b
+	m_ := l - 0.1055613458*a - 0.0638541728*b
+	s_ := l - 0.0894841775*a - 1.2914855480*b
+	ll, mm, ss := l_*l_*l_, m_*m_*m_, s_*s_*s_
+
+	return Color{
+		R:     fromLinear(+4.0767416621*ll - 3.3077115913*mm + 0.2309699292*ss),
+		G:     fromLinear(-1.2684380046*ll + 2.6097574011*mm - 0.3413193965*ss),
+		B:     fromLinear(-0.0041960863*ll - 0.7034186147*mm + 1.7076147010*ss),
+		Space: ColorOKLCH,
+	}
+}
+
+// parse "#f00", "#ff0000", "rgb(255 0 0)", "hsl(0 100% 50%)" or
+// "oklch(62.8% 0.258 29.2)"
+func ParseColor(s string) (Color, bool) {
+	if colorHexRe.MatchString(s) {
+		s = s[1:]
+		if len(s) == 3 {
+			s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+		}
+		x, _ := strconv.ParseUint(s, 16, 32)
+		return Color{R: float64(x>>16) / 255, G: float64((x>>8)&0xff) / 255, B: float64(x&0xff) / 255}, true
+	}
+
+	match := colorFuncRe.FindStringSubmatch(strings.ToLower(s))
+	if match == nil {
+		return Color{}, false
+	}
+	fields := strings.FieldsFunc(match[2], func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) != 3 {
+		return Color{}, false
+	}
+	var xyz [3]float64
+	for ii, field := range fields {
+		x, err := strconv.ParseFloat(strings.TrimSuffix(field, "%"), 64)
+		if err != nil {
+			return Color{}, false
+		}
+		xyz[ii] = x
+	}
+	switch match[1] {
+	case "rgb":
+		return NewColorRGB(xyz[0], xyz[1], xyz[2]), true
+	case "hsl":
+		return NewColorHSL(xyz[0], xyz[1], xyz[2]), true
+	}
+	return NewColorOKLCH(xyz[0], xyz[1], xyz[2]), true
+}
+
+// could this be the start of a hex color?
+func IsPartialColor(s string) bool {
+	return partialColorRe.MatchString(s)
+}
+
+// look up a tailwind color, like "blue-400"
+func TailwindColor(name string) (Color, bool) {
+	hex, ok := TailwindColors[strings.ToLower(name)]
+	if !ok {
+		return Color{}, false
+	}
+	color, _ := ParseColor(string(hex))
+	return color, true
+}
+
+//
+// conversions
+//
+
+// same color, different space
+func (c Color) In(space ColorSpace) Color {
+	c.Space = space
+	return c
+}
+
+func (c Color) RGB() (float64, float64, float64) {
+	return c.R * 255, c.G * 255, c.B * 255
+}
+
+func (c Color) Hex() string {
+	r, g, b := c.RGB()
+	return fmt.Sprintf("#%02x%02x%02x", int(math.Round(r)), int(math.Round(g)), int(math.Round(b)))
+}
+
+// h is degrees, s and l are 0..100
+func (c Color) HSL() (float64, float64, float64) {
+	hi, lo := math.Max(c.R, math.Max(c.G, c.B)), math.Min(c.R, math.Min(c.G, c.B))
+	l := (hi + lo) / 2
+	if hi == lo {
+		return 0, 0, l * 100
+	}
+	d := hi - lo
+	s := d / (1 - math.Abs(2*l-1))
+	var h float64
+	switch hi {
+	case c.R:
+		h = math.Mod((c.G-c.B)/d+6, 6)
+	case c.G:
+		h = (c.B-c.R)/d + 2
+	default:
+		h = (c.R-c.G)/d + 4
+	}
+	return h * 60, s * 100, l * 100
+}
+
+// l is 0..100, c is chroma, h is degrees
+func (c Color) OKLCH() (float64, float64, float64) {
+	r, g, b := toLinear(c.R), toLinear(c.G), toLinear(c.B)
+	l_ := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m_ := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s_ := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	l := 0.2104542553*l_ + 0.7936177850*m_ - 0.0040720468*s_
+	aa := 1.9779984951*l_ - 2.4285922050*m_ + 0.4505937099*s_
+	bb := 0.0259040371*l_ + 0.7827717662*m_ - 0.8086757660*s_
+
+	chroma := math.Hypot(aa, bb)
+	h := math.Mod(math.Atan2(bb, aa)*180/math.Pi+360, 360)
+	if chroma < 0.0001 {
+		chroma, h = 0, 0
+	}
+	return l * 100, chroma, h
+}
+
+func (c Color) String() string {
+	switch c.Space {
+	case ColorRGB:
+		r, g, b := c.RGB()
+		return fmt.Sprintf("rgb(%s %s %s)", fmtFloat(r, 0), fmtFloat(g, 0), fmtFloat(b, 0))
+	case ColorHSL:
+		h, s, l := c.HSL()
+		return fmt.Sprintf("hsl(%s %s%% %s%%)", fmtFloat(h, 1), fmtFloat(s, 1), fmtFloat(l, 1))
+	case ColorOKLCH:
+		l, chroma, h := c.OKLCH()
+		return fmt.Sprintf("oklch(%s%% %s %s)", fmtFloat(l, 1), fmtFloat(chroma, 3), fmtFloat(h, 1))
+	case ColorHex:
+	}
+	return c.Hex()
+}
+
+// a little swatch for the stack pane, or "" if v isn't a color
+func Swatch(v Value) string {
+	color, ok := v.(Color)
+	if !ok {
+		return ""
+	}
+	return " " + LG.Background(lipgloss.Color(color.Hex())).Render("  ")
+}
+
+//
+// helpers
+//
+
+// convert to a Color, or black if v isn't a Color
+func toColor(v Value) Color {
+	color, _ := v.(Color)
+	return color
+}
+
+func hue2rgb(p, q, t float64) float64 {
+	t = math.Mod(t+1, 1)
+	switch {
+	case t < 1.0/6:
+		return p + (q-p)*6*t
+	case t < 1.0/2:
+		return q
+	case t < 2.0/3:
+		return p + (q-p)*(2.0/3-t)*6
+	}
+	return p
+}
+
+// srgb gamma
+func toLinear(x float64) float64 {
+	if x <= 0.04045 {
+		return x / 12.92
+	}
+	return math.Pow((x+0.055)/1.055, 2.4)
+}
+
+func fromLinear(x float64) float64 {
+	if x <= 0.0031308 {
+		return clamp01(x * 12.92)
+	}
+	return clamp01(1.055*math.Pow(x, 1/2.4) - 0.055)
+}
+
+func clamp01(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+// round to places and trim trailing zeros
+func fmtFloat(x float64, places int) string {
+	pow := math.Pow(10, float64(places))
+	x = math.Round(x*pow) / pow
+	if x == 0 {
+		x = 0 // no -0
+	}
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
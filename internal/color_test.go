@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input string
+		hex   string
+	}{
+		{"#f80", "#ff8800"},
+		{"#60A5FA", "#60a5fa"},
+		{"rgb(96 165 250)", "#60a5fa"},
+		{"rgb(96, 165, 250)", "#60a5fa"},
+		{"hsl(0 100% 50%)", "#ff0000"},
+		{"hsl(120, 100, 25)", "#008000"},
+		{"oklch(62.8% 0.258 29.2)", "#ff0000"},
+		{"oklch(0% 0 0)", "#000000"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			color, ok := ParseColor(tc.input)
+			assert.True(t, ok)
+			assert.Equal(t, tc.hex, color.Hex())
+		})
+	}
+
+	for _, s := range []string{"", "#", "#ff", "#gggggg", "rgb(1 2)", "foo(1 2 3)", "hsl(a b c)"} {
+		_, ok := ParseColor(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestColorString(t *testing.T) {
+	color, _ := ParseColor("#60a5fa")
+	assert.Equal(t, "#60a5fa", color.String())
+	assert.Equal(t, "rgb(96 165 250)", color.In(ColorRGB).String())
+	assert.Equal(t, "hsl(213.1 93.9% 67.8%)", color.In(ColorHSL).String())
+	assert.Equal(t, "oklch(71.4% 0.143 254.6)", color.In(ColorOKLCH).String())
+
+	// round trip
+	for _, space := range []ColorSpace{ColorHex, ColorRGB, ColorHSL, ColorOKLCH} {
+		parsed, ok := ParseColor(color.In(space).String())
+		assert.True(t, ok)
+		assert.Equal(t, "#60a5fa", parsed.Hex())
+		assert.Equal(t, space, parsed.Space)
+	}
+
+	// grays have no hue
+	gray, _ := ParseColor("#808080")
+	assert.Equal(t, "hsl(0 0% 50.2%)", gray.In(ColorHSL).String())
+	assert.Equal(t, "oklch(60% 0 0)", gray.In(ColorOKLCH).String())
+}
+
+func TestTailwindColor(t *testing.T) {
+	color, ok := TailwindColor("Blue-400")
+	assert.True(t, ok)
+	assert.Equal(t, "#60a5fa", color.Hex())
+	_, ok = TailwindColor("blue-401")
+	assert.False(t, ok)
+}
+
+func TestSwatch(t *testing.T) {
+	color, _ := ParseColor("#ff0000")
+	assert.NotEmpty(t, Swatch(color))
+	assert.Empty(t, Swatch(One))
+}
@@ -2,6 +2,12 @@ package internal
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/atotto/clipboard"
 	"github.com/samber/lo"
@@ -9,78 +15,531 @@ import (
 )
 
 type Command struct {
-	Name  string
+	Name string
+	// shown in help and the palette, see Categories
+	Category string
+	Desc     string
+	// if set, the command needs an argument (like a name) and this is the
+	// prompt. Valid/fn see it as c.arg
+	Arg   string
 	fn    interface{}
 	key   string
 	fmt   string
 	valid func(*Calculator) error
+	// the top of the stack is a count n, and fn needs n more values below it
+	counted bool
+}
+
+// the key bound to this command, if any
+func (c Command) Key() string {
+	return c.key
 }
 
 //
 // the commands
 //
 
+// command categories, in the order they appear in help
+var Categories = []string{
+	"arithmetic", "math", "trig", "rounding", "number theory", "fractions",
+	"complex", "units", "dates", "statistics", "finance", "programmer", "display", "colors", "stack", "registers", "macros", "misc",
+}
+
 var Commands = []Command{
-	{Name: "ADD", key: "+", fn: add, fmt: "%s + %s = %s"},
-	{Name: "CLEAR", key: "esc", fn: clear},
-	{Name: "DIV", key: "/", fn: div, valid: validNot0, fmt: "%s / %s = %s"},
-	{Name: "DROP", fn: drop},
-	{Name: "DUP", key: "xxx", fn: dup},
-	{Name: "FACT", key: "!", fn: fact, valid: validFact, fmt: "%s! = %s"},
-	{Name: "INV", key: "i", fn: inv, fmt: "1 / %s = %s"},
-	{Name: "LN", fn: ln, valid: validGt0, fmt: "ln(%s) = %s"}, // bad key, don't do it
-	{Name: "LOG", key: "l", fn: log, valid: validGt0, fmt: "log(%s) = %s"},
-	{Name: "MOD", key: "%", fn: mod, fmt: "%s mod %s = %s"},
-	{Name: "MUL", key: "*", fn: mul, fmt: "%s * %s = %s"},
-	{Name: "NEG", key: "n", fn: neg},
-	{Name: "PI", key: "p", fn: pi},
-	{Name: "POW", key: "^", fn: pow, fmt: "%s ^ %s = %s"},
-	{Name: "SQRT", key: "@", fn: sqrt, valid: validGte0, fmt: "sqrt(%s) = %s"},
-	{Name: "SUB", key: "-", fn: sub, fmt: "%s - %s = %s"},
-	{Name: "SWAP", key: "s", fn: swap},
-	{Name: "YANK", key: "y", fn: yank},
-	{Name: "UNDO", key: "z", fn: undo, valid: validUndo},
-}
-
-var CommandsByName = lo.KeyBy(Commands, func(c Command) string { return c.Name })
-var CommandsByKey = lo.KeyBy(lo.Filter(Commands, func(c Command, _ int) bool { return c.key != "" }),
-	func(c Command) string { return c.key })
+	{Name: "%CH", Category: "finance", Desc: "percent change from y to x", fn: percentChange, valid: validPercentBase, fmt: "%s -> %s = %s%%"},
+	{Name: "%OF", Category: "finance", Desc: "x percent of y", fn: percent, fmt: "%s * %s%% = %s"},
+	{Name: "%T", Category: "finance", Desc: "x as a percent of the total y", fn: percentTotal, valid: validPercentBase, fmt: "%s of %s = %s%%"},
+	{Name: "->DATE", Category: "dates", Desc: "convert unix timestamp to date", fn: toDate, valid: validUnix, fmt: "date(%s) = %s"},
+	{Name: "->HEX", Category: "colors", Desc: "convert color to hex", fn: toHex, valid: validColor},
+	{Name: "->HSL", Category: "colors", Desc: "convert color to hsl", fn: toHSL, valid: validColor},
+	{Name: "->NUM", Category: "fractions", Desc: "convert fraction to decimal", fn: toDecimal},
+	{Name: "->OKLCH", Category: "colors", Desc: "convert color to oklch", fn: toOKLCH, valid: validColor},
+	{Name: "->Q", Category: "fractions", Desc: "convert to the closest simple fraction", fn: toQ},
+	{Name: "->RGB", Category: "colors", Desc: "convert color to rgb", fn: toRGB, valid: validColor},
+	{Name: "->UNIX", Category: "dates", Desc: "convert date to unix timestamp", fn: toUnix, valid: validDate, fmt: "unix(%s) = %s"},
+	{Name: "-ROT", Category: "stack", Desc: "rotate top three values down", fn: unrot},
+	{Name: "ABS", Category: "math", Desc: "absolute value", fn: abs, fmt: "abs(%s) = %s"},
+	{Name: "ACOS", Category: "trig", Desc: "arc cosine", key: "alt+c", fn: acos, valid: validUnit, fmt: "acos(%s) = %s"},
+	{Name: "ADD", Category: "arithmetic", Desc: "add x + y", key: "+", fn: add, valid: validAdd, fmt: "%s + %s = %s"},
+	{Name: "AMORT", Category: "finance", Desc: "amortize n payments, push interest, principal and balance", fn: amort, valid: validAmort, fmt: "amort(%s) = %s"},
+	{Name: "AND", Category: "programmer", Desc: "bitwise and", key: "&", fn: and, valid: validInts, fmt: "%s and %s = %s"},
+	{Name: "ANGLE", Category: "trig", Desc: "cycle angle mode, deg/rad/grad", key: "a", fn: angle},
+	{Name: "ARG", Category: "complex", Desc: "angle of a complex number", fn: arg, valid: validNoUnits, fmt: "arg(%s) = %s"},
+	{Name: "ASIN", Category: "trig", Desc: "arc sine", key: "alt+s", fn: asin, valid: validUnit, fmt: "asin(%s) = %s"},
+	{Name: "ATAN", Category: "trig", Desc: "arc tangent", key: "alt+t", fn: atan, fmt: "atan(%s) = %s"},
+	{Name: "BEGIN", Category: "finance", Desc: "toggle TVM payments at the start of each period", fn: begin},
+	{Name: "BIN", Category: "programmer", Desc: "binary radix", fn: bin},
+	{Name: "BIND", Category: "macros", Desc: "bind a macro to a key", Arg: "macro and key", fn: bind, valid: validBind},
+	{Name: "C->P", Category: "complex", Desc: "split complex into r and angle", fn: cToP, valid: validNumber},
+	{Name: "C->R", Category: "complex", Desc: "split complex into real and imaginary", fn: cToR, valid: validNumber},
+	{Name: "CEIL", Category: "rounding", Desc: "round up to an integer", fn: ceil, fmt: "ceil(%s) = %s"},
+	{Name: "CLEAR", Category: "stack", Desc: "clear the stack", key: "esc", fn: clear},
+	{Name: "CLS", Category: "statistics", Desc: "clear the Σ sums, same as CLΣ", fn: clearSigma},
+	{Name: "CLTVM", Category: "finance", Desc: "clear the TVM values", fn: clearTVM},
+	{Name: "CLΣ", Category: "statistics", Desc: "clear the Σ sums", fn: clearSigma},
+	{Name: "COMPLEX", Category: "complex", Desc: "toggle complex results, sqrt of -1 is i", fn: complexMode},
+	{Name: "CONJ", Category: "complex", Desc: "complex conjugate", fn: conj, valid: validNoUnits, fmt: "conj(%s) = %s"},
+	{Name: "CONVERT", Category: "units", Desc: "convert to another unit, like ft or km/h", Arg: "unit", key: "U", fn: convert, valid: validConvert, fmt: "%s = %s"},
+	{Name: "COS", Category: "trig", Desc: "cosine", key: "C", fn: cos, fmt: "cos(%s) = %s"},
+	{Name: "DEC", Category: "programmer", Desc: "decimal radix", fn: dec},
+	{Name: "DECIMALS", Category: "fractions", Desc: "toggle showing fractions as decimals", fn: decimals},
+	{Name: "DEF", Category: "macros", Desc: "define a macro, like hyp dup * swap dup * + sqrt", Arg: "program", key: ";", fn: def, valid: validDef},
+	{Name: "DEG", Category: "trig", Desc: "angles in degrees", fn: deg},
+	{Name: "DEPTH", Category: "stack", Desc: "push the stack depth", fn: depth},
+	{Name: "DIV", Category: "arithmetic", Desc: "divide x / y", key: "/", fn: div, valid: validDiv, fmt: "%s / %s = %s"},
+	{Name: "DROP", Category: "stack", Desc: "drop the top value", key: "backspace", fn: drop},
+	{Name: "DROP2", Category: "stack", Desc: "drop the top two values", fn: drop2},
+	{Name: "DROPN", Category: "stack", Desc: "drop n values", fn: dropn, counted: true},
+	{Name: "DUP", Category: "stack", Desc: "duplicate the top value", key: "enter", fn: dup},
+	{Name: "DUPN", Category: "stack", Desc: "duplicate n values", fn: dupn, counted: true},
+	{Name: "ENG", Category: "display", Desc: "engineering notation, n places", fn: eng, valid: validDigits},
+	{Name: "EXACT", Category: "fractions", Desc: "toggle exact fractions, 1 3 / stays 1/3", key: "E", fn: exact},
+	{Name: "FACT", Category: "math", Desc: "factorial", key: "!", fn: fact, valid: validFact, fmt: "%s! = %s"},
+	{Name: "FACTOR", Category: "number theory", Desc: "prime factorization", fn: factor, valid: validFactor, fmt: "factor(%s) = %s"},
+	{Name: "FIX", Category: "display", Desc: "show n decimal places", fn: fix, valid: validDigits},
+	{Name: "FLOOR", Category: "rounding", Desc: "round down to an integer", fn: floor, fmt: "floor(%s) = %s"},
+	{Name: "FRAC", Category: "rounding", Desc: "fractional part", fn: frac, fmt: "frac(%s) = %s"},
+	{Name: "FV", Category: "finance", Desc: "store TVM future value", fn: storeFV},
+	{Name: "GCD", Category: "number theory", Desc: "greatest common divisor", fn: gcd, valid: validInts, fmt: "gcd(%s, %s) = %s"},
+	{Name: "GRAD", Category: "trig", Desc: "angles in gradians", fn: grad},
+	{Name: "GROUP", Category: "display", Desc: "toggle thousands separators", key: ",", fn: group},
+	{Name: "HEX", Category: "programmer", Desc: "hexadecimal radix", fn: hex},
+	{Name: "HSL", Category: "colors", Desc: "make a color from h, s, l", fn: hsl, valid: validHSL},
+	{Name: "I/YR", Category: "finance", Desc: "store TVM interest rate, yearly percent", fn: storeI},
+	{Name: "INV", Category: "arithmetic", Desc: "inverse, 1/x", key: "i", fn: inv, valid: validNot0, fmt: "1 / %s = %s"},
+	{Name: "IRR", Category: "finance", Desc: "internal rate of return of the stack, first flow is now", fn: irr, valid: validIRR, fmt: "irr(%s) = %s%%"},
+	{Name: "ISPRIME", Category: "number theory", Desc: "1 if prime, 0 otherwise", fn: isprime, valid: validInt, fmt: "isprime(%s) = %s"},
+	{Name: "LCM", Category: "number theory", Desc: "least common multiple", fn: lcm, valid: validInts, fmt: "lcm(%s, %s) = %s"},
+	{Name: "LINREG", Category: "statistics", Desc: "linear regression, push slope, intercept and r", fn: linreg, valid: validLinreg},
+	{Name: "LN", Category: "math", Desc: "natural log", fn: ln, valid: validLn, fmt: "ln(%s) = %s"}, // bad key, don't do it
+	{Name: "LOG", Category: "math", Desc: "log base 10", key: "l", fn: log, valid: validGt0, fmt: "log(%s) = %s"},
+	{Name: "MARGIN", Category: "finance", Desc: "price for cost y with margin x percent", fn: margin, valid: validMargin, fmt: "%s at %s%% margin = %s"},
+	{Name: "MARKUP", Category: "finance", Desc: "price for cost y with markup x percent", fn: markup, fmt: "%s at %s%% markup = %s"},
+	{Name: "MAX", Category: "statistics", Desc: "max of the stack", fn: maximum, fmt: "max(%s) = %s"},
+	{Name: "MAXN", Category: "statistics", Desc: "max of n values", fn: maximum, counted: true, fmt: "max(%s) = %s"},
+	{Name: "MEAN", Category: "statistics", Desc: "average of the stack", fn: mean, fmt: "mean(%s) = %s"},
+	{Name: "MEANN", Category: "statistics", Desc: "average of n values", fn: mean, counted: true, fmt: "mean(%s) = %s"},
+	{Name: "MEDIAN", Category: "statistics", Desc: "median of the stack", fn: median, fmt: "median(%s) = %s"},
+	{Name: "MEDIANN", Category: "statistics", Desc: "median of n values", fn: median, counted: true, fmt: "median(%s) = %s"},
+	{Name: "MIN", Category: "statistics", Desc: "min of the stack", fn: minimum, fmt: "min(%s) = %s"},
+	{Name: "MINN", Category: "statistics", Desc: "min of n values", fn: minimum, counted: true, fmt: "min(%s) = %s"},
+	{Name: "MOD", Category: "arithmetic", Desc: "x modulo y", key: "%", fn: mod, fmt: "%s mod %s = %s"},
+	{Name: "MUL", Category: "arithmetic", Desc: "multiply x * y", key: "*", fn: mul, valid: validMul, fmt: "%s * %s = %s"},
+	{Name: "N", Category: "finance", Desc: "store TVM number of periods", fn: storeN},
+	{Name: "NEG", Category: "arithmetic", Desc: "negate +/- sign", key: "n", fn: neg},
+	{Name: "NOT", Category: "programmer", Desc: "bitwise not", key: "~", fn: not, valid: validInt, fmt: "not %s = %s"},
+	{Name: "NOW", Category: "dates", Desc: "push the current date and time", fn: now},
+	{Name: "NPV", Category: "finance", Desc: "net present value of the stack at a rate, first flow is now", Arg: "rate, like 8", fn: npv, valid: validRate, fmt: "npv(%s) = %s"},
+	{Name: "OCT", Category: "programmer", Desc: "octal radix", fn: oct},
+	{Name: "OKLCH", Category: "colors", Desc: "make a color from l, c, h", fn: oklch, valid: validOKLCH},
+	{Name: "OR", Category: "programmer", Desc: "bitwise or", key: "|", fn: or, valid: validInts, fmt: "%s or %s = %s"},
+	{Name: "OVER", Category: "stack", Desc: "copy the second value to the top", key: "o", fn: over},
+	{Name: "P->C", Category: "complex", Desc: "make complex from r and angle", fn: pToC, fmt: "polar(%s, %s) = %s"},
+	{Name: "P/YR", Category: "finance", Desc: "store TVM payments per year, 12 if unset", fn: storePYR, valid: validGt0},
+	{Name: "PERCENTILE", Category: "statistics", Desc: "percentile of the stack, like 90", Arg: "percentile", fn: percentile, valid: validPercentile, fmt: "percentile(%s) = %s"},
+	{Name: "PERCENTILEN", Category: "statistics", Desc: "percentile of n values, like 90", Arg: "percentile", fn: percentile, valid: validPercentile, counted: true, fmt: "percentile(%s) = %s"},
+	{Name: "PI", Category: "math", Desc: "push pi", key: "p", fn: pi},
+	{Name: "PICK", Category: "stack", Desc: "copy the nth value to the top", fn: pick, valid: validPick, counted: true},
+	{Name: "PMT", Category: "finance", Desc: "store TVM payment", fn: storePMT},
+	{Name: "POLAR", Category: "complex", Desc: "toggle polar display, like 5∠53.13", fn: polar},
+	{Name: "POW", Category: "arithmetic", Desc: "x ^ y power", key: "^", fn: pow, valid: validPow, fmt: "%s ^ %s = %s"},
+	{Name: "PREC", Category: "math", Desc: "set working precision, in digits", fn: prec, valid: validPrecision},
+	{Name: "PROD", Category: "statistics", Desc: "product of the stack", fn: prod, fmt: "prod(%s) = %s"},
+	{Name: "PRODN", Category: "statistics", Desc: "product of n values", fn: prod, counted: true, fmt: "prod(%s) = %s"},
+	{Name: "PSDEV", Category: "statistics", Desc: "population standard deviation of the stack", fn: psdev, fmt: "psdev(%s) = %s"},
+	{Name: "PSDEVN", Category: "statistics", Desc: "population standard deviation of n values", fn: psdev, counted: true, fmt: "psdev(%s) = %s"},
+	{Name: "PURGE", Category: "registers", Desc: "delete a register", Arg: "register name", fn: purge, valid: validRegister},
+	{Name: "PV", Category: "finance", Desc: "store TVM present value", fn: storePV},
+	{Name: "PVAR", Category: "statistics", Desc: "population variance of the stack", fn: pvar, fmt: "pvar(%s) = %s"},
+	{Name: "PVARN", Category: "statistics", Desc: "population variance of n values", fn: pvar, counted: true, fmt: "pvar(%s) = %s"},
+	{Name: "R->C", Category: "complex", Desc: "make complex x + yi", fn: rToC, fmt: "complex(%s, %s) = %s"},
+	{Name: "RAD", Category: "trig", Desc: "angles in radians", fn: rad},
+	{Name: "RADIX", Category: "programmer", Desc: "cycle radix, dec/hex/bin/oct", key: "r", fn: radix},
+	{Name: "RCL", Category: "registers", Desc: "recall a register", Arg: "register name", key: "M", fn: rcl, valid: validRegister},
+	{Name: "REDO", Category: "misc", Desc: "redo", key: "Z", fn: redo, valid: validRedo},
+	{Name: "RGB", Category: "colors", Desc: "make a color from r, g, b", fn: rgb, valid: validRGB},
+	{Name: "ROLL", Category: "stack", Desc: "move the nth value to the top", fn: roll, counted: true},
+	{Name: "ROT", Category: "stack", Desc: "rotate top three values up", key: "R", fn: rot},
+	{Name: "ROUND", Category: "rounding", Desc: "round to an integer", fn: round, fmt: "round(%s) = %s"},
+	{Name: "ROUNDMODE", Category: "rounding", Desc: "cycle rounding mode", fn: roundmode},
+	{Name: "ROUNDN", Category: "rounding", Desc: "round x to y places", fn: roundn, valid: validPlaces, fmt: "round(%s, %s) = %s"},
+	{Name: "S+", Category: "statistics", Desc: "add an x, y point, same as Σ+", fn: sigmaPlus, fmt: "Σ+ %s, %s"},
+	{Name: "S-", Category: "statistics", Desc: "remove an x, y point, same as Σ-", fn: sigmaMinus, fmt: "Σ- %s, %s"},
+	{Name: "SCI", Category: "display", Desc: "scientific notation, n places", fn: sci, valid: validDigits},
+	{Name: "SDEV", Category: "statistics", Desc: "sample standard deviation of the stack", fn: sdev, valid: validSample, fmt: "sdev(%s) = %s"},
+	{Name: "SDEVN", Category: "statistics", Desc: "sample standard deviation of n values", fn: sdev, valid: validSampleN, counted: true, fmt: "sdev(%s) = %s"},
+	{Name: "SHL", Category: "programmer", Desc: "shift left", key: "<", fn: shl, valid: validShift, fmt: "%s << %s = %s"},
+	{Name: "SHR", Category: "programmer", Desc: "shift right", key: ">", fn: shr, valid: validShift, fmt: "%s >> %s = %s"},
+	{Name: "SI", Category: "display", Desc: "SI prefixes like k, M and µ, n places", fn: si, valid: validDigits},
+	{Name: "SIGN", Category: "math", Desc: "sign, -1/0/1", fn: sign, fmt: "sign(%s) = %s"},
+	{Name: "SIGNED", Category: "programmer", Desc: "signed integers", fn: signed},
+	{Name: "SIN", Category: "trig", Desc: "sine", key: "S", fn: sin, fmt: "sin(%s) = %s"},
+	{Name: "SOLVE", Category: "finance", Desc: "solve for a TVM value", Arg: "N, I/YR, PV, PMT or FV", fn: solve, valid: validSolve},
+	{Name: "SQRT", Category: "arithmetic", Desc: "square root", key: "@", fn: sqrt, valid: validSqrt, fmt: "sqrt(%s) = %s"},
+	{Name: "STD", Category: "display", Desc: "standard display", fn: std},
+	{Name: "STO", Category: "registers", Desc: "store in a register", Arg: "register name", key: "m", fn: sto, valid: validRegisterName},
+	{Name: "SUB", Category: "arithmetic", Desc: "subtract x - y", key: "-", fn: sub, valid: validSub, fmt: "%s - %s = %s"},
+	{Name: "SUM", Category: "statistics", Desc: "sum of the stack", fn: sum, fmt: "sum(%s) = %s"},
+	{Name: "SUMN", Category: "statistics", Desc: "sum of n values", fn: sum, counted: true, fmt: "sum(%s) = %s"},
+	{Name: "SWAP", Category: "stack", Desc: "swap the top two values", key: "s", fn: swap},
+	{Name: "TAILWIND", Category: "colors", Desc: "push a tailwind color, like blue-400", Arg: "tailwind color", key: "t", fn: tailwind, valid: validTailwind},
+	{Name: "TAN", Category: "trig", Desc: "tangent", key: "T", fn: tan, valid: validTan, fmt: "tan(%s) = %s"},
+	{Name: "TODAY", Category: "dates", Desc: "push today's date", fn: today},
+	{Name: "TRUNC", Category: "rounding", Desc: "round toward zero", fn: trunc, fmt: "trunc(%s) = %s"},
+	{Name: "UBASE", Category: "units", Desc: "convert to SI base units", fn: ubase},
+	{Name: "UNDEF", Category: "macros", Desc: "delete a macro", Arg: "macro name", fn: undef, valid: validMacro},
+	{Name: "UNDO", Category: "misc", Desc: "undo", key: "z", fn: undo, valid: validUndo},
+	{Name: "UNIT", Category: "units", Desc: "attach a unit, like m or /s", Arg: "unit", fn: unit, valid: validAttachUnit},
+	{Name: "UNSIGNED", Category: "programmer", Desc: "unsigned integers", fn: unsigned},
+	{Name: "UVAL", Category: "units", Desc: "drop the unit, keep the number", fn: uval},
+	{Name: "VAR", Category: "statistics", Desc: "sample variance of the stack", fn: variance, valid: validSample, fmt: "var(%s) = %s"},
+	{Name: "VARN", Category: "statistics", Desc: "sample variance of n values", fn: variance, valid: validSampleN, counted: true, fmt: "var(%s) = %s"},
+	{Name: "WSIZE", Category: "programmer", Desc: "set word size, 8/16/32/64", key: "w", fn: wsize, valid: validWordSize},
+	{Name: "XOR", Category: "programmer", Desc: "bitwise xor", key: "x", fn: xor, valid: validInts, fmt: "%s xor %s = %s"},
+	{Name: "YANK", Category: "misc", Desc: "copy to clipboard", key: "y", fn: yank},
+	{Name: "YANKD", Category: "misc", Desc: "copy the displayed value to clipboard", key: "Y", fn: yankd},
+	{Name: "Σ+", Category: "statistics", Desc: "add an x, y point for linear regression", fn: sigmaPlus, fmt: "Σ+ %s, %s"},
+	{Name: "Σ-", Category: "statistics", Desc: "remove an x, y point from linear regression", fn: sigmaMinus, fmt: "Σ- %s, %s"},
+}
+
+var CommandsByName, CommandsByKey map[string]Command
+
+// built in init, since macro commands look up other commands
+func init() {
+	CommandsByName = lo.KeyBy(Commands, func(c Command) string { return c.Name })
+	CommandsByKey = lo.KeyBy(lo.Filter(Commands, func(c Command, _ int) bool { return c.key != "" }),
+		func(c Command) string { return c.key })
+}
+
+// register names are letters, digits and underscores
+var registerNameRe = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// find a command by key or name (case insensitive), ie "+" or "add"
+func LookupCommand(token string) (Command, bool) {
+	if cmd, ok := CommandsByKey[token]; ok {
+		return cmd, true
+	}
+	cmd, ok := CommandsByName[strings.ToUpper(token)]
+	return cmd, ok
+}
 
 // these are sometimes run directly
 const (
-	DROP = "DROP"
-	DUP  = "DUP"
-	NEG  = "NEG"
-	UNDO = "UNDO"
-	YANK = "YANK"
+	DEF   = "DEF"
+	DROP  = "DROP"
+	DUP   = "DUP"
+	NEG   = "NEG"
+	REDO  = "REDO"
+	UNDO  = "UNDO"
+	YANK  = "YANK"
+	YANKD = "YANKD"
 )
 
 //
 // commands
 //
 
-func add(_ *Calculator, a, b Num) Num { return a.Add(b) }
-func clear(c *Calculator)             { c.Clear() }
-func div(_ *Calculator, a, b Num) Num { return a.Div(b) }
-func drop(_ *Calculator, _ Num)       { /* nop */ }
-func dup(c *Calculator, a Num)        { c.Push(a, a) }
-func fact(_ *Calculator, a Num) Num   { return Factorial(a) }
-func swap(c *Calculator, a, b Num)    { c.Push(b, a) }
-func inv(_ *Calculator, a Num) Num    { return One.Div(a) }
-func ln(_ *Calculator, a Num) Num     { return Ln(a) }
-func log(_ *Calculator, a Num) Num    { return Ln(a).Div(Ln10) }
-func mod(_ *Calculator, a, b Num) Num { return a.Mod(b) }
-func mul(_ *Calculator, a, b Num) Num { return a.Mul(b) }
-func neg(_ *Calculator, a Num) Num    { return a.Neg() }
-func pi(_ *Calculator) Num            { return Pi }
-func pow(_ *Calculator, a, b Num) Num { return Pow(a, b) }
-func sqrt(_ *Calculator, a Num) Num   { return Pow(a, Half) }
-func sub(_ *Calculator, a, b Num) Num { return a.Sub(b) }
-func undo(c *Calculator)              { c.Undo() }
-func yank(c *Calculator, a Num) {
-	c.Push(a)
+func abs(c *Calculator, a Number) Number {
+	switch a := a.(type) {
+	case Complex:
+		return a.Abs(c.precision)
+	case Quantity:
+		return Quantity{Value: a.Value.Abs(), Unit: a.Unit}
+	}
+	return unary(a, Num.Abs, (*big.Rat).Abs)
+}
+func acos(c *Calculator, a Num) Num { return c.fromRadians(Acos(a, c.precision)) }
+func amort(c *Calculator, n Num) []Num {
+	interest, principal, balance := c.tvm().amortize(int(n.IntPart()))
+	c.storeTVM(tvmPV, NormalizePrec(balance, c.precision))
+	c.storeTVM(tvmN, c.tvmValues[tvmN].Sub(n))
+	return []Num{interest, principal, balance}
+}
+func add(c *Calculator, a, b Value) Value {
+	if IsTime(a) || IsTime(b) {
+		return dateAdd(a, b)
+	}
+	return binary(a.(Number), b.(Number), false, Num.Add, (*big.Rat).Add, Complex.Add,
+		func(x, y Quantity) Number { return x.Add(y, c.precision) })
+}
+func and(c *Calculator, a, b Num) Num    { return c.bitwise(a, b, (*big.Int).And) }
+func angle(c *Calculator)                { c.angle = (c.angle + 1) % AngleMode(len(angleModeNames)) }
+func arg(c *Calculator, a Number) Number { return c.fromRadians(toComplex(a).Arg(c.precision)) }
+func asin(c *Calculator, a Num) Num      { return c.fromRadians(Asin(a, c.precision)) }
+func atan(c *Calculator, a Num) Num      { return c.fromRadians(a.Atan()) }
+func begin(c *Calculator)                { c.begin = !c.begin }
+func bin(c *Calculator)                  { c.radix = Bin }
+func bind(c *Calculator) {
+	macro := lo.Must(c.parseBind(c.arg))
+	c.macros[macro.Name] = macro
+}
+func cToP(c *Calculator, a Value) {
+	z := toComplex(a)
+	c.Push(z.Abs(c.precision), c.fromRadians(z.Arg(c.precision)))
+}
+func cToR(c *Calculator, a Value) {
+	z := toComplex(a)
+	c.Push(z.Re, z.Im)
+}
+func ceil(_ *Calculator, a Num) Num       { return a.Ceil() }
+func clear(c *Calculator)                 { c.Clear() }
+func clearTVM(c *Calculator)              { c.tvmValues = nil }
+func clearSigma(c *Calculator)            { c.sigmaValues = nil }
+func complexMode(c *Calculator)           { c.complex = !c.complex }
+func conj(_ *Calculator, a Number) Number { return toComplex(a).Conj() }
+func convert(c *Calculator, a Value) Value {
+	return lo.Must(a.(Quantity).Convert(lo.Must(ParseUnit(c.arg)), c.precision))
+}
+func cos(c *Calculator, a Num) Num { return c.toRadians(a).Cos() }
+func dec(c *Calculator)            { c.radix = Dec }
+func decimals(c *Calculator)       { c.decimals = !c.decimals }
+func def(c *Calculator) {
+	if c.macros == nil {
+		c.macros = map[string]Macro{}
+	}
+	macro := lo.Must(c.parseMacro(c.arg))
+	c.macros[macro.Name] = macro
+}
+func deg(c *Calculator)       { c.angle = Deg }
+func depth(c *Calculator) Num { return decimal.NewFromInt(int64(c.Len())) }
+func div(c *Calculator, a, b Value) Value {
+	if IsTime(a) || IsTime(b) {
+		return dateDiv(a, b, c.precision)
+	}
+	return binary(a.(Number), b.(Number), c.exact, func(x, y Num) Num { return Div(x, y, c.precision) }, (*big.Rat).Quo,
+		func(x, y Complex) Complex { return x.Div(y, c.precision) },
+		func(x, y Quantity) Number { return x.Div(y, c.precision) })
+}
+func drop(_ *Calculator, _ Value)        { /* nop */ }
+func drop2(_ *Calculator, _, _ Value)    { /* nop */ }
+func dropn(c *Calculator, n Num)         { c.stack = c.stack[:c.Len()-int(n.IntPart())] }
+func dup(c *Calculator, a Value)         { c.PushValue(a, a) }
+func dupn(c *Calculator, n Num)          { c.PushValue(c.stack[c.Len()-int(n.IntPart()):]...) }
+func eng(c *Calculator, n Num)           { c.SetDisplayMode(Eng, int(n.IntPart())) }
+func exact(c *Calculator)                { c.exact = !c.exact }
+func fact(_ *Calculator, a Num) Num      { return lo.Must(Factorial(a)) }
+func factor(c *Calculator, a Num) []Num  { return lo.Must(c.factor(a)) }
+func fix(c *Calculator, n Num)           { c.SetDisplayMode(Fix, int(n.IntPart())) }
+func floor(_ *Calculator, a Num) Num     { return a.Floor() }
+func frac(_ *Calculator, a Num) Num      { return a.Sub(a.Truncate(0)) }
+func gcd(_ *Calculator, a, b Num) Num    { return decimal.NewFromBigInt(Gcd(a.BigInt(), b.BigInt()), 0) }
+func grad(c *Calculator)                 { c.angle = Grad }
+func group(c *Calculator)                { c.grouping = !c.grouping }
+func hex(c *Calculator)                  { c.radix = Hex }
+func swap(c *Calculator, a, b Value)     { c.PushValue(b, a) }
+func inv(c *Calculator, a Number) Number { return div(c, One, a).(Number) }
+func isprime(_ *Calculator, a Num) Num {
+	return lo.Ternary(IsPrime(a.BigInt()), One, decimal.Zero)
+}
+func lcm(_ *Calculator, a, b Num) Num { return decimal.NewFromBigInt(Lcm(a.BigInt(), b.BigInt()), 0) }
+func linreg(c *Calculator) {
+	slope, intercept, r := lo.Must3(c.linreg())
+	c.Push(slope, intercept, r)
+}
+func ln(c *Calculator, a Number) Number {
+	if c.wantsComplex(a) {
+		return toComplex(a).Ln(c.precision)
+	}
+	return Ln(ToNum(a), c.precision)
+}
+func irr(c *Calculator, values []Num) Num { return lo.Must(c.irr(values)) }
+func log(c *Calculator, a Num) Num        { return Div(Ln(a, c.precision), Ln10(c.precision), c.precision) }
+func margin(c *Calculator, cost, pct Num) Num {
+	return Div(cost, One.Sub(Div(pct, decimal.NewFromInt(100), c.precision)), c.precision)
+}
+func markup(c *Calculator, cost, pct Num) Num {
+	return cost.Add(Div(cost.Mul(pct), decimal.NewFromInt(100), c.precision))
+}
+func maximum(_ *Calculator, values []Num) Num { return decimal.Max(values[0], values[1:]...) }
+func mean(c *Calculator, values []Num) Num    { return Mean(values, c.precision) }
+func median(c *Calculator, values []Num) Num  { return Median(values, c.precision) }
+func minimum(_ *Calculator, values []Num) Num { return decimal.Min(values[0], values[1:]...) }
+func mod(_ *Calculator, a, b Num) Num         { return a.Mod(b) }
+func mul(_ *Calculator, a, b Value) Value {
+	if IsTime(a) || IsTime(b) {
+		return dateMul(a, b)
+	}
+	return binary(a.(Number), b.(Number), false, Num.Mul, (*big.Rat).Mul, Complex.Mul, Quantity.Mul)
+}
+func neg(_ *Calculator, a Number) Number {
+	switch a := a.(type) {
+	case Complex:
+		return a.Neg()
+	case Quantity:
+		return a.Neg()
+	}
+	return unary(a, Num.Neg, (*big.Rat).Neg)
+}
+func not(c *Calculator, a Num) Num   { return c.wrap(new(big.Int).Not(a.BigInt())) }
+func oct(c *Calculator)              { c.radix = Oct }
+func or(c *Calculator, a, b Num) Num { return c.bitwise(a, b, (*big.Int).Or) }
+func now(_ *Calculator) Value        { return DateOf(time.Now().Truncate(time.Second)) }
+func npv(c *Calculator, values []Num) Num {
+	return NPV(values, lo.Must(decimal.NewFromString(c.arg)), c.precision)
+}
+func over(c *Calculator, a, b Value)      { c.PushValue(a, b, a) }
+func percent(c *Calculator, a, b Num) Num { return Div(a.Mul(b), decimal.NewFromInt(100), c.precision) }
+func percentChange(c *Calculator, a, b Num) Num {
+	return Div(b.Sub(a).Mul(decimal.NewFromInt(100)), a, c.precision)
+}
+func percentTotal(c *Calculator, total, part Num) Num {
+	return Div(part.Mul(decimal.NewFromInt(100)), total, c.precision)
+}
+func percentile(c *Calculator, values []Num) Num {
+	return Percentile(values, lo.Must(decimal.NewFromString(c.arg)), c.precision)
+}
+func pi(c *Calculator) Num                   { return Pi(c.precision) }
+func pick(c *Calculator, n Num)              { c.PushValue(c.stack[c.Len()-int(n.IntPart())]) }
+func polar(c *Calculator)                    { c.polar = !c.polar }
+func pToC(c *Calculator, r, theta Num) Value { return Polar(r, c.toRadians(theta)) }
+func pow(c *Calculator, a, b Number) Number {
+	if q, ok := a.(Quantity); ok {
+		return q.Pow(int(ToNum(b).IntPart()), c.precision)
+	}
+	x, y := ToNum(a), ToNum(b)
+	if IsComplex(a) || IsComplex(b) || (c.complex && x.IsNegative() && !y.IsInteger()) {
+		return toComplex(a).Pow(toComplex(b), c.precision)
+	}
+	// fractions to integer powers stay exact
+	_, frac := a.(Frac)
+	if (frac || c.exact) && y.IsInteger() && y.Abs().IntPart() <= maxExactPower && (!x.IsZero() || !y.IsNegative()) {
+		return RatValue(powRat(toRat(a), y.IntPart()))
+	}
+	return Pow(x, y, c.precision)
+}
+func prec(c *Calculator, n Num)            { c.precision = int(n.IntPart()) }
+func prod(_ *Calculator, values []Num) Num { return Product(values) }
+func psdev(c *Calculator, values []Num) Num {
+	return Pow(Variance(values, false, c.precision), Half, c.precision)
+}
+func purge(c *Calculator)                  { delete(c.registers, c.arg) }
+func pvar(c *Calculator, values []Num) Num { return Variance(values, false, c.precision) }
+func rToC(_ *Calculator, a, b Num) Value   { return Complex{Re: a, Im: b} }
+func rad(c *Calculator)                    { c.angle = Rad }
+func radix(c *Calculator)                  { c.radix = (c.radix + 1) % Radix(len(radixNames)) }
+func rcl(c *Calculator) Value              { return c.registers[c.arg] }
+func redo(c *Calculator)                   { c.Redo() }
+func roll(c *Calculator, n Num) {
+	if n.IsZero() {
+		return
+	}
+	ii := c.Len() - int(n.IntPart())
+	v := c.stack[ii]
+	c.stack = append(slices.Delete(c.stack, ii, ii+1), v)
+}
+func rot(c *Calculator, a, b, x Value) { c.PushValue(b, x, a) }
+func round(c *Calculator, a Num) Num   { return c.rounding.Round(a, 0) }
+func roundmode(c *Calculator) {
+	c.rounding = (c.rounding + 1) % RoundingMode(len(roundingModeNames))
+}
+func roundn(c *Calculator, a, b Num) Num {
+	return c.rounding.Round(a, int32(b.IntPart())) //nolint:gosec
+}
+func sdev(c *Calculator, values []Num) Num {
+	return Pow(Variance(values, true, c.precision), Half, c.precision)
+}
+func sci(c *Calculator, n Num)           { c.SetDisplayMode(Sci, int(n.IntPart())) }
+func shl(c *Calculator, a, b Num) Num    { return c.shift(a, int(b.IntPart())) }
+func shr(c *Calculator, a, b Num) Num    { return c.shift(a, -int(b.IntPart())) }
+func si(c *Calculator, n Num)            { c.SetDisplayMode(SI, int(n.IntPart())) }
+func sigmaMinus(c *Calculator, x, y Num) { c.accumulate(x, y, -1) }
+func sigmaPlus(c *Calculator, x, y Num)  { c.accumulate(x, y, 1) }
+func sign(_ *Calculator, a Num) Num      { return decimal.NewFromInt(int64(a.Sign())) }
+func signed(c *Calculator)               { c.unsigned = false }
+func sin(c *Calculator, a Num) Num       { return c.toRadians(a).Sin() }
+func solve(c *Calculator) Num {
+	x := NormalizePrec(lo.Must(c.solve(c.arg)), c.precision)
+	c.storeTVM(c.arg, x)
+	return x
+}
+func sqrt(c *Calculator, a Number) Number {
+	if q, ok := a.(Quantity); ok {
+		return lo.Must(q.Sqrt(c.precision))
+	}
+	if c.wantsComplex(a) {
+		return toComplex(a).Sqrt(c.precision)
+	}
+	return Pow(ToNum(a), Half, c.precision)
+}
+func std(c *Calculator)             { c.display = Std }
+func sto(c *Calculator, a Value)    { c.storeRegister(c.arg, a) }
+func storeFV(c *Calculator, a Num)  { c.storeTVM(tvmFV, a) }
+func storeI(c *Calculator, a Num)   { c.storeTVM(tvmI, a) }
+func storeN(c *Calculator, a Num)   { c.storeTVM(tvmN, a) }
+func storePMT(c *Calculator, a Num) { c.storeTVM(tvmPMT, a) }
+func storePV(c *Calculator, a Num)  { c.storeTVM(tvmPV, a) }
+func storePYR(c *Calculator, a Num) { c.storeTVM(tvmPYR, a) }
+func sub(c *Calculator, a, b Value) Value {
+	if IsTime(a) || IsTime(b) {
+		return dateSub(a, b)
+	}
+	return binary(a.(Number), b.(Number), false, Num.Sub, (*big.Rat).Sub, Complex.Sub,
+		func(x, y Quantity) Number { return x.Add(y.Neg(), c.precision) })
+}
+func sum(_ *Calculator, values []Num) Num { return Sum(values) }
+func tan(c *Calculator, a Num) Num        { return c.toRadians(a).Tan() }
+func toDecimal(_ *Calculator, a Num) Num  { return a }
+func toQ(c *Calculator, a Number) Number {
+	if _, ok := a.(Num); !ok {
+		return a // already a Frac, or Complex/Quantity
+	}
+	return RatValue(BestRat(ToNum(a), c.precision))
+}
+func trunc(_ *Calculator, a Num) Num { return a.Truncate(0) }
+func today(_ *Calculator) Value {
+	return Date{Time: DateOf(time.Now()).Time.Truncate(day)}
+}
+func ubase(c *Calculator, a Number) Number {
+	if q, ok := a.(Quantity); ok {
+		return q.Base(c.precision)
+	}
+	return a
+}
+func unit(c *Calculator, a Value) Value {
+	return toQuantity(a).Mul(Quantity{Value: One, Unit: lo.Must(ParseUnit(c.arg))})
+}
+func unrot(c *Calculator, a, b, x Value) { c.PushValue(x, a, b) }
+func undef(c *Calculator)                { delete(c.macros, c.arg) }
+func undo(c *Calculator)                 { c.Undo() }
+func unsigned(c *Calculator)             { c.unsigned = true }
+func uval(_ *Calculator, a Number) Number {
+	if q, ok := a.(Quantity); ok {
+		return q.Value
+	}
+	return a
+}
+func wsize(c *Calculator, a Num)      { c.wordSize = int(a.IntPart()) }
+func xor(c *Calculator, a, b Num) Num { return c.bitwise(a, b, (*big.Int).Xor) }
+func yank(c *Calculator, a Value) {
+	c.PushValue(a)
 	_ = clipboard.WriteAll(a.String())
 }
+func variance(c *Calculator, values []Num) Num { return Variance(values, true, c.precision) }
+func yankd(c *Calculator, a Value) {
+	c.PushValue(a)
+	_ = clipboard.WriteAll(c.Format(a))
+}
+
+//
+// color commands
+//
+
+func hsl(_ *Calculator, h, s, l Num) Value {
+	return NewColorHSL(h.InexactFloat64(), s.InexactFloat64(), l.InexactFloat64())
+}
+func oklch(_ *Calculator, l, c, h Num) Value {
+	return NewColorOKLCH(l.InexactFloat64(), c.InexactFloat64(), h.InexactFloat64())
+}
+func rgb(_ *Calculator, r, g, b Num) Value {
+	return NewColorRGB(r.InexactFloat64(), g.InexactFloat64(), b.InexactFloat64())
+}
+func tailwind(c *Calculator) Value         { return lo.Must(TailwindColor(c.arg)) }
+func toDate(_ *Calculator, a Value) Value  { return UnixDate(ToNum(a)) }
+func toUnix(_ *Calculator, a Value) Value  { return a.(Date).Unix() }
+func toHex(_ *Calculator, a Value) Value   { return toColor(a).In(ColorHex) }
+func toHSL(_ *Calculator, a Value) Value   { return toColor(a).In(ColorHSL) }
+func toOKLCH(_ *Calculator, a Value) Value { return toColor(a).In(ColorOKLCH) }
+func toRGB(_ *Calculator, a Value) Value   { return toColor(a).In(ColorRGB) }
 
 //
 // helpers
@@ -88,7 +547,8 @@ func yank(c *Calculator, a Num) {
 
 func validFact(c *Calculator) error {
 	a := c.Peek()
-	if a.IsNegative() || !IsInt(a) {
+	// IsInteger, not IsInt, since the precision might be higher than usual
+	if a.IsNegative() || !a.IsInteger() {
 		return errors.New("not a positive int")
 	}
 	if a.GreaterThan(decimal.NewFromFloat(100)) {
@@ -96,6 +556,26 @@ func validFact(c *Calculator) error {
 	}
 	return nil
 }
+func validFactor(c *Calculator) error {
+	a := c.Peek()
+	if !a.IsInteger() || a.LessThan(Two) {
+		return errors.New("not an int > 1")
+	}
+	_, err := c.factor(a)
+	return err
+}
+func validInt(c *Calculator) error {
+	if !c.Peek().IsInteger() {
+		return errors.New("not an int")
+	}
+	return nil
+}
+func validInts(c *Calculator) error {
+	if !ToNum(c.PeekN(0)).IsInteger() || !ToNum(c.PeekN(1)).IsInteger() {
+		return errors.New("not an int")
+	}
+	return nil
+}
 func validGt0(c *Calculator) error {
 	if !c.Peek().IsPositive() {
 		return errors.New("not positive")
@@ -109,11 +589,318 @@ func validGte0(c *Calculator) error {
 	return nil
 }
 func validNot0(c *Calculator) error {
-	if c.Peek().IsZero() {
+	var zero bool
+	switch v := c.PeekValue().(type) {
+	case Num, Frac, Quantity:
+		zero = toQuantity(v).Value.IsZero()
+	case Duration:
+		zero = v == 0
+	}
+	if zero {
+		return errors.New("divide by zero")
+	}
+	return nil
+}
+
+// sqrt and ln of negatives are fine in COMPLEX mode
+func validSqrt(c *Calculator) error {
+	if q, ok := c.PeekValue().(Quantity); ok {
+		if q.Value.IsNegative() {
+			return errors.New("not positive")
+		}
+		_, err := q.Sqrt(c.precision)
+		return err
+	}
+	if c.wantsComplex(c.PeekValue()) {
+		return nil
+	}
+	return validGte0(c)
+}
+func validLn(c *Calculator) error {
+	if err := validNoUnits(c); err != nil {
+		return err
+	}
+	if c.wantsComplex(c.PeekValue()) {
+		return nil
+	}
+	return validGt0(c)
+}
+
+// a real or complex number
+func validNumber(c *Calculator) error {
+	if v := c.PeekValue(); !IsNum(v) && !IsComplex(v) {
+		return errors.New("not a number")
+	}
+	return nil
+}
+
+//
+// units
+//
+
+func validNoUnits(c *Calculator) error {
+	if IsQuantity(c.PeekValue()) {
+		return errors.New("has units")
+	}
+	return nil
+}
+
+//
+// arithmetic, checking units and dates
+//
+
+func validAdd(c *Calculator) error { return validArith(c, "+", true) }
+func validSub(c *Calculator) error { return validArith(c, "-", true) }
+func validMul(c *Calculator) error { return validArith(c, "*", false) }
+func validDiv(c *Calculator) error {
+	if err := validArith(c, "/", false); err != nil {
+		return err
+	}
+	return validNot0(c)
+}
+func validArith(c *Calculator, op string, sameUnits bool) error {
+	a, b := c.PeekN(1), c.PeekN(0)
+	if err := dateError(a, b, op); err != nil {
+		return err
+	}
+	return unitsError(a, b, sameUnits)
+}
+func validPow(c *Calculator) error {
+	a, b := c.PeekN(1), c.PeekN(0)
+	x, y := toComplex(a), toComplex(b)
+	if q, ok := a.(Quantity); ok {
+		x = Complex{Re: q.Value}
+	}
+	switch {
+	case x.Re.IsZero() && x.Im.IsZero() && y.Re.IsNegative():
+		return errors.New("divide by zero")
+	case x.Re.IsZero() && x.Im.IsZero() && y.Re.IsZero() && y.Im.IsZero():
+		return errors.New("0 ^ 0 is undefined")
+	case x.Re.IsNegative() && !y.Re.IsInteger() && !IsComplex(a) && !IsComplex(b) && !c.complex:
+		// COMPLEX mode makes these complex, see pow
+		return errors.New("fractional power of a negative")
+	case IsQuantity(b):
+		return errors.New("exponent has units")
+	case IsQuantity(a) && (!IsNum(b) || !ToNum(b).IsInteger()):
+		return errors.New("unit powers must be integers")
+	case IsQuantity(a) && ToNum(b).Abs().GreaterThan(decimal.NewFromInt(maxUnitPower)):
+		return errors.New("unit power too large")
+	}
+	return nil
+}
+func validConvert(c *Calculator) error {
+	q, ok := c.PeekValue().(Quantity)
+	if !ok {
+		return errors.New("no units")
+	}
+	unit, err := ParseUnit(c.arg)
+	if err != nil {
+		return err
+	}
+	_, err = q.Convert(unit, c.precision)
+	return err
+}
+func validReal(c *Calculator) error {
+	if !IsNum(c.PeekValue()) {
+		return errors.New("not a number")
+	}
+	return nil
+}
+func validDate(c *Calculator) error {
+	if !IsDate(c.PeekValue()) {
+		return errors.New("not a date")
+	}
+	return nil
+}
+func validUnix(c *Calculator) error {
+	if err := validReal(c); err != nil {
+		return err
+	}
+	if x := c.Peek(); x.LessThan(decimal.NewFromInt(minDate.Unix())) || x.GreaterThan(decimal.NewFromInt(maxDate.Unix())) {
+		return errors.New("date out of range")
+	}
+	return nil
+}
+
+//
+// statistics
+//
+
+// sample variance needs at least two values
+func validSample(c *Calculator) error {
+	if c.Len() < 2 {
+		return errors.New("too few arguments")
+	}
+	return nil
+}
+func validSampleN(c *Calculator) error {
+	if c.Peek().LessThan(Two) {
+		return errors.New("too few arguments")
+	}
+	return nil
+}
+func validPercentile(c *Calculator) error {
+	p, err := decimal.NewFromString(c.arg)
+	if err != nil || p.IsNegative() || p.GreaterThan(decimal.NewFromInt(100)) {
+		return errors.New("percentile must be 0 to 100")
+	}
+	return nil
+}
+func validLinreg(c *Calculator) error {
+	_, _, _, err := c.linreg()
+	return err
+}
+
+//
+// finance
+//
+
+func validSolve(c *Calculator) error {
+	if !slices.Contains(tvmNames, c.arg) {
+		return errors.New("not a TVM value")
+	}
+	_, err := c.solve(c.arg)
+	return err
+}
+func validAmort(c *Calculator) error {
+	switch {
+	case !c.Peek().IsInteger() || !c.Peek().IsPositive():
+		return errors.New("bad count")
+	case c.begin:
+		return errors.New("amortization needs END mode")
+	case c.Peek().GreaterThan(c.tvm().n):
+		return errors.New("more than N periods")
+	case c.Peek().GreaterThan(decimal.NewFromInt(maxAmortPeriods)):
+		return errors.New("too many periods")
+	}
+	return nil
+}
+func validRate(c *Calculator) error {
+	if _, err := decimal.NewFromString(c.arg); err != nil {
+		return errors.New("bad rate")
+	}
+	return nil
+}
+func validIRR(c *Calculator) error {
+	_, err := c.irr(MapV(c.stack, ToNum))
+	return err
+}
+func validPercentBase(c *Calculator) error {
+	if ToNum(c.PeekN(1)).IsZero() {
 		return errors.New("divide by zero")
 	}
 	return nil
 }
+func validMargin(c *Calculator) error {
+	if !c.Peek().LessThan(decimal.NewFromInt(100)) {
+		return errors.New("margin must be under 100")
+	}
+	return nil
+}
+
+func validAttachUnit(c *Calculator) error {
+	if v := c.PeekValue(); !IsNum(v) && !IsQuantity(v) {
+		return errors.New("not a number")
+	}
+	_, err := ParseUnit(c.arg)
+	return err
+}
+
+func validTan(c *Calculator) error {
+	if NormalizePrec(c.toRadians(c.Peek()).Cos(), c.precision).IsZero() {
+		return errors.New("undefined")
+	}
+	return nil
+}
+
+// |x| <= 1, for asin/acos
+func validUnit(c *Calculator) error {
+	if c.Peek().Abs().GreaterThan(One) {
+		return errors.New("out of range")
+	}
+	return nil
+}
+
+func validPick(c *Calculator) error {
+	if c.Peek().IsZero() {
+		return errors.New("bad count")
+	}
+	return nil
+}
+
+func validPlaces(c *Calculator) error {
+	if err := validInt(c); err != nil {
+		return err
+	}
+	if c.Peek().Abs().GreaterThan(decimal.NewFromInt(100)) {
+		return errors.New("too many places")
+	}
+	return nil
+}
+
+func validDigits(c *Calculator) error {
+	if !c.Peek().IsInteger() || c.PeekInt() < 0 || c.PeekInt() > maxDigits {
+		return fmt.Errorf("digits must be 0 to %d", maxDigits)
+	}
+	return nil
+}
+
+func validPrecision(c *Calculator) error {
+	if !c.Peek().IsInteger() || c.PeekInt() < MinPrecision || c.PeekInt() > MaxPrecision {
+		return fmt.Errorf("precision must be %d to %d", MinPrecision, MaxPrecision)
+	}
+	return nil
+}
+
+func validShift(c *Calculator) error {
+	if err := validInts(c); err != nil {
+		return err
+	}
+	if n := c.Peek(); n.IsNegative() || n.GreaterThan(decimal.NewFromInt(int64(c.wordSize))) {
+		return errors.New("bad shift")
+	}
+	return nil
+}
+
+func validWordSize(c *Calculator) error {
+	if !slices.Contains(WordSizes, c.PeekInt()) || !c.Peek().IsInteger() {
+		return errors.New("word size must be 8, 16, 32 or 64")
+	}
+	return nil
+}
+
+// are the top three values in range?
+func validRange3(c *Calculator, low, high [3]float64) error {
+	for ii := range 3 {
+		x := ToNum(c.PeekN(2 - ii)).InexactFloat64()
+		if x < low[ii] || x > high[ii] {
+			return errors.New("out of range")
+		}
+	}
+	return nil
+}
+
+func validColor(c *Calculator) error {
+	if _, ok := c.PeekValue().(Color); !ok {
+		return errors.New("not a color")
+	}
+	return nil
+}
+func validHSL(c *Calculator) error {
+	return validRange3(c, [3]float64{-360, 0, 0}, [3]float64{360, 100, 100})
+}
+func validOKLCH(c *Calculator) error {
+	return validRange3(c, [3]float64{0, 0, -360}, [3]float64{100, 0.5, 360})
+}
+func validRGB(c *Calculator) error {
+	return validRange3(c, [3]float64{0, 0, 0}, [3]float64{255, 255, 255})
+}
+func validTailwind(c *Calculator) error {
+	if _, ok := TailwindColor(c.arg); !ok {
+		return errors.New("unknown color")
+	}
+	return nil
+}
 
 func validUndo(c *Calculator) error {
 	if len(c.undo) == 0 {
@@ -121,3 +908,41 @@ func validUndo(c *Calculator) error {
 	}
 	return nil
 }
+
+func validRegister(c *Calculator) error {
+	if _, ok := c.registers[c.arg]; !ok {
+		return errors.New("no such register")
+	}
+	return nil
+}
+
+func validRegisterName(c *Calculator) error {
+	if !registerNameRe.MatchString(c.arg) {
+		return errors.New("bad register name")
+	}
+	return nil
+}
+
+func validDef(c *Calculator) error {
+	_, err := c.parseMacro(c.arg)
+	return err
+}
+
+func validBind(c *Calculator) error {
+	_, err := c.parseBind(c.arg)
+	return err
+}
+
+func validMacro(c *Calculator) error {
+	if _, ok := c.macros[c.arg]; !ok {
+		return errors.New("no such macro")
+	}
+	return nil
+}
+
+func validRedo(c *Calculator) error {
+	if len(c.redo) == 0 {
+		return errors.New("nothing to redo")
+	}
+	return nil
+}
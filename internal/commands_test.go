@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,22 +14,69 @@ func TestEachCommand(t *testing.T) {
 		inputs  []float64
 		outputs []float64
 	}{
+		{"-ROT", []float64{1, 2, 3}, []float64{3, 1, 2}},
+		{"ABS", []float64{-3}, []float64{3}},
+		{"ACOS", []float64{0.5}, []float64{60}},
 		{"ADD", []float64{3, 5}, []float64{8}},
+		{"AND", []float64{12, 10}, []float64{8}},
+		{"ASIN", []float64{1}, []float64{90}},
+		{"ATAN", []float64{1}, []float64{45}},
+		{"CEIL", []float64{-2.5}, []float64{-2}},
 		{"CLEAR", []float64{1, 2}, []float64{}},
+		{"COS", []float64{60}, []float64{0.5}},
+		{"DEPTH", []float64{1, 2, 3}, []float64{1, 2, 3, 3}},
 		{"DIV", []float64{8, 2}, []float64{4}},
 		{"DROP", []float64{1, 2, 3}, []float64{1, 2}},
+		{"DROP2", []float64{1, 2, 3}, []float64{1}},
+		{"DROPN", []float64{1, 2, 3, 2}, []float64{1}},
 		{"DUP", []float64{1, 2, 3}, []float64{1, 2, 3, 3}},
+		{"DUPN", []float64{1, 2, 3, 2}, []float64{1, 2, 3, 2, 3}},
 		{"FACT", []float64{5}, []float64{120}},
+		{"FACTOR", []float64{360}, []float64{2, 2, 2, 3, 3, 5}},
+		{"FLOOR", []float64{-2.5}, []float64{-3}},
+		{"FRAC", []float64{-2.25}, []float64{-0.25}},
+		{"GCD", []float64{12, -18}, []float64{6}},
+		{"IRR", []float64{-100, 110}, []float64{10}},
+		{"ISPRIME", []float64{97}, []float64{1}},
+		{"LCM", []float64{4, 6}, []float64{12}},
 		{"INV", []float64{2}, []float64{0.5}},
 		{"LOG", []float64{10}, []float64{1}},
+		{"MAX", []float64{3, 7, 5}, []float64{7}},
+		{"MEAN", []float64{1, 2, 3, 4}, []float64{2.5}},
+		{"MEANN", []float64{9, 2, 4, 2}, []float64{9, 3}},
+		{"MEDIAN", []float64{5, 1, 3}, []float64{3}},
+		{"MIN", []float64{3, 7, 5}, []float64{3}},
 		{"MOD", []float64{5, 3}, []float64{2}},
 		{"MUL", []float64{3, 5}, []float64{15}},
 		{"NEG", []float64{3}, []float64{-3}},
+		{"NOT", []float64{5}, []float64{-6}},
+		{"OR", []float64{12, 10}, []float64{14}},
+		{"OVER", []float64{1, 2}, []float64{1, 2, 1}},
 		{"PI", []float64{}, []float64{3.1415926536}},
+		{"PICK", []float64{1, 2, 3, 3}, []float64{1, 2, 3, 1}},
 		{"POW", []float64{2, 3}, []float64{8}},
+		{"PROD", []float64{2, 3, 4}, []float64{24}},
+		{"PSDEV", []float64{2, 4, 4, 4, 5, 5, 7, 9}, []float64{2}},
+		{"PVAR", []float64{2, 4, 4, 4, 5, 5, 7, 9}, []float64{4}},
+		{"ROLL", []float64{1, 2, 3, 4, 3}, []float64{1, 3, 4, 2}},
+		{"ROLL", []float64{1, 2, 0}, []float64{1, 2}},
+		{"ROT", []float64{1, 2, 3}, []float64{2, 3, 1}},
+		{"ROUND", []float64{2.5}, []float64{3}},
+		{"ROUNDN", []float64{1.2345, 2}, []float64{1.23}},
+		{"SDEV", []float64{1, 2, 3, 4, 5}, []float64{1.5811388301}},
+		{"SHL", []float64{3, 4}, []float64{48}},
+		{"SHR", []float64{-48, 4}, []float64{-3}},
+		{"SIGN", []float64{-7}, []float64{-1}},
+		{"SIN", []float64{30}, []float64{0.5}},
 		{"SQRT", []float64{9}, []float64{3}},
 		{"SUB", []float64{5, 3}, []float64{2}},
+		{"SUM", []float64{1, 2, 3}, []float64{6}},
+		{"SUMN", []float64{1, 2, 3, 2}, []float64{1, 5}},
 		{"SWAP", []float64{1, 2}, []float64{2, 1}},
+		{"TAN", []float64{45}, []float64{1}},
+		{"TRUNC", []float64{-2.7}, []float64{-2}},
+		{"VAR", []float64{1, 2, 3, 4, 5}, []float64{2.5}},
+		{"XOR", []float64{12, 10}, []float64{6}},
 	}
 
 	c := NewCalculator()
@@ -37,7 +86,7 @@ func TestEachCommand(t *testing.T) {
 			c.PushFloat64(tc.inputs...)
 			testRun(c, tc.cmd)
 
-			outputs := MapV(c.GetStack(), func(x Num) float64 { return x.InexactFloat64() })
+			outputs := MapV(c.GetStack(), func(x Value) float64 { return ToNum(x).InexactFloat64() })
 			assert.Equal(t, tc.outputs, outputs)
 		})
 	}
@@ -53,11 +102,152 @@ func TestCommandUndo(t *testing.T) {
 	assert.Equal(t, 1, c.PopInt())
 }
 
+func TestCommandAngle(t *testing.T) {
+	c := NewCalculator()
+	assert.Equal(t, Deg, c.GetAngle())
+	testRun(c, "ANGLE")
+	assert.Equal(t, Rad, c.GetAngle())
+	c.PushFloat64(1)
+	testRun(c, "ASIN")
+	assert.Equal(t, "1.5707963268", c.Pop().String())
+	testRun(c, "ANGLE")
+	assert.Equal(t, Grad, c.GetAngle())
+	c.PushInt(100)
+	testRun(c, "SIN")
+	assert.Equal(t, 1, c.PopInt())
+	testRun(c, "ANGLE")
+	assert.Equal(t, Deg, c.GetAngle())
+	testRun(c, "RAD")
+	assert.Equal(t, []string{"RAD"}, c.GetModes())
+}
+
+func TestCommandRoundingMode(t *testing.T) {
+	c := NewCalculator()
+	for _, expected := range []float64{3, 2, 2} {
+		c.PushFloat64(2.5)
+		testRun(c, "ROUND")
+		assert.Equal(t, expected, c.PopFloat64())
+		testRun(c, "ROUNDMODE")
+	}
+	assert.Equal(t, HalfUp, c.GetRounding())
+}
+
+func TestCommandColors(t *testing.T) {
+	c := NewCalculator()
+	c.PushInt(96, 165, 250)
+	assert.NoError(t, c.Run("RGB"))
+	assert.Equal(t, "rgb(96 165 250)", c.PeekValue().String())
+	assert.NoError(t, c.Run("->HEX"))
+	assert.Equal(t, "#60a5fa", c.PeekValue().String())
+	assert.NoError(t, c.Run("->HSL"))
+	assert.Equal(t, "hsl(213.1 93.9% 67.8%)", c.PeekValue().String())
+	assert.NoError(t, c.Run("DUP"))
+	assert.Equal(t, 2, c.Len())
+
+	// colors aren't numbers
+	assert.EqualError(t, c.Run("ADD"), "not a number")
+	c.Clear()
+	c.PushInt(1)
+	assert.EqualError(t, c.Run("->OKLCH"), "not a color")
+
+	c.PushInt(0, 50)
+	assert.NoError(t, c.Run("HSL"))
+	assert.Equal(t, "#808080", toColor(c.PeekValue()).Hex())
+	c.PushInt(70, 0, 0)
+	assert.NoError(t, c.Run("OKLCH"))
+	c.PushInt(256, 0, 0)
+	assert.EqualError(t, c.Run("RGB"), "out of range")
+
+	// tailwind
+	assert.EqualError(t, c.Run("TAILWIND"), "missing tailwind color")
+	assert.EqualError(t, c.RunArg("TAILWIND", "nope"), "unknown color")
+	assert.NoError(t, c.RunArg("TAILWIND", "blue-400"))
+	assert.Equal(t, "#60a5fa", c.PeekValue().String())
+}
+
+func TestCommandCounted(t *testing.T) {
+	c := NewCalculator()
+	c.PushInt(1, 2, 3)
+	assert.EqualError(t, c.Run("DROPN"), "too few arguments")
+	c.PushFloat64(1.5)
+	assert.EqualError(t, c.Run("DROPN"), "bad count")
+	c.PushInt(-1)
+	assert.EqualError(t, c.Run("DUPN"), "bad count")
+	c.PushInt(0)
+	assert.EqualError(t, c.Run("PICK"), "bad count")
+
+	c.SetStackString([]string{"1", "#ff0000", "2"})
+	assert.NoError(t, c.Run("PICK"))
+	assert.Equal(t, []string{"1", "#ff0000", "1"}, c.GetStackString())
+	c.PushInt(0)
+	assert.NoError(t, c.Run("DROPN"))
+	assert.Equal(t, 3, c.Len())
+}
+
+func TestCommandRegisters(t *testing.T) {
+	c := NewCalculator()
+	assert.EqualError(t, c.RunArg("RCL", "x"), "no such register")
+	c.PushInt(42)
+	assert.EqualError(t, c.RunArg("STO", "1x"), "bad register name")
+	assert.NoError(t, c.RunArg("STO", "tax_rate"))
+	assert.Equal(t, 0, c.Len())
+	assert.NoError(t, c.RunArg("RCL", "tax_rate"))
+	assert.NoError(t, c.RunArg("RCL", "tax_rate"))
+	assert.Equal(t, []string{"42", "42"}, c.GetStackString())
+
+	// undo covers registers too
+	assert.NoError(t, c.RunArg("PURGE", "tax_rate"))
+	assert.Empty(t, c.GetRegisters())
+	assert.NoError(t, c.Run(UNDO))
+	assert.Equal(t, map[string]string{"tax_rate": "42"}, c.GetRegistersString())
+
+	// tokens
+	assert.NoError(t, c.RunTokens([]string{"#f00", "sto", "red", "rcl", "tax_rate"}))
+	assert.Equal(t, "#ff0000", c.GetRegisters()["red"].String())
+	assert.Equal(t, []string{"42", "42", "42"}, c.GetStackString())
+}
+
+func TestCommandPrec(t *testing.T) {
+	c := NewCalculator()
+	assert.NoError(t, c.RunTokens([]string{"2", "log"}))
+	assert.Equal(t, "0.3010299957", c.PeekValue().String())
+	assert.NoError(t, c.RunTokens([]string{"drop", "30", "prec", "pi", "2", "log", "1", "3", "/"}))
+	assert.Equal(t, []string{
+		"3.14159265358979323846264338328",
+		"0.301029995663981195213738894724",
+		"0.333333333333333333333333333333",
+	}, c.GetStackString())
+	assert.Contains(t, c.GetModes(), "PREC 30")
+
+	// trig uses the precise pi too
+	assert.NoError(t, c.RunTokens([]string{"clear", "90", "sin", "180", "cos"}))
+	assert.Equal(t, []string{"1", "-1"}, c.GetStackString())
+
+	c.PushInt(5)
+	assert.EqualError(t, c.Run("PREC"), "precision must be 6 to 100")
+
+	// not quite an int at this precision
+	assert.NoError(t, c.RunTokens([]string{"clear", "20", "prec", "5.0000001"}))
+	assert.EqualError(t, c.Run("FACT"), "not a positive int")
+}
+
 func TestCommandMaps(t *testing.T) {
 	assert.Equal(t, "ADD", CommandsByKey["+"].Name)
 	assert.Equal(t, "ADD", CommandsByName["ADD"].Name)
 }
 
+// help is generated in this order
+func TestCommandsSorted(t *testing.T) {
+	assert.True(t, slices.IsSortedFunc(Commands, func(a, b Command) int { return strings.Compare(a.Name, b.Name) }))
+}
+
+func TestCommandCategories(t *testing.T) {
+	for _, cmd := range Commands {
+		assert.Contains(t, Categories, cmd.Category, cmd.Name)
+		assert.NotEmpty(t, cmd.Desc, cmd.Name)
+	}
+}
+
 func TestCommandsValid(t *testing.T) {
 	var (
 		c = NewCalculator()
@@ -79,6 +269,38 @@ func TestCommandsValid(t *testing.T) {
 	assert.NoError(t, validGte0(c))
 	assert.NoError(t, validNot0(c))
 
+	// trig
+	c.PushFloat64(1.5)
+	assert.Error(t, validUnit(c))
+	c.PushFloat64(-1)
+	assert.NoError(t, validUnit(c))
+	c.PushInt(90)
+	assert.Error(t, validTan(c))
+	c.PushInt(45)
+	assert.NoError(t, validTan(c))
+
+	// ints
+	c.PushFloat64(1.5, 2)
+	assert.NoError(t, validInt(c))
+	assert.Error(t, validInts(c))
+	c.PushInt(65)
+	assert.Error(t, validShift(c))
+	assert.Error(t, validWordSize(c))
+	c.PushInt(16)
+	assert.NoError(t, validWordSize(c))
+
+	// factor
+	c.PushInt(1)
+	assert.Error(t, validFactor(c))
+	c.PushInt(1234567)
+	assert.NoError(t, validFactor(c))
+
+	// places
+	c.PushInt(101)
+	assert.Error(t, validPlaces(c))
+	c.PushInt(-2)
+	assert.NoError(t, validPlaces(c))
+
 	// few more errors cases for validFact
 	for _, x := range []float64{0.5, 9999} {
 		c.PushFloat64(x)
@@ -92,15 +314,42 @@ func testRun(c *Calculator, name string) {
 		fn(c)
 	case func(*Calculator, Num):
 		fn(c, c.Pop())
+	case func(*Calculator, Value):
+		fn(c, c.PopValue())
 	case func(*Calculator) Num:
 		c.Push(fn(c))
+	case func(*Calculator) Value:
+		c.PushValue(fn(c))
 	case func(*Calculator, Num) Num:
 		c.Push(fn(c, c.Pop()))
+	case func(*Calculator, Value) Value:
+		c.PushValue(fn(c, c.PopValue()))
+	case func(*Calculator, Number) Number:
+		c.PushValue(fn(c, c.PopValue().(Number)))
+	case func(*Calculator, Num) []Num:
+		c.Push(fn(c, c.Pop())...)
+	case func(*Calculator, []Num) Num:
+		c.Push(fn(c, c.popList(CommandsByName[name].counted)))
 	case func(*Calculator, Num, Num):
 		b, a := c.Pop(), c.Pop()
 		fn(c, a, b)
+	case func(*Calculator, Value, Value):
+		b, a := c.PopValue(), c.PopValue()
+		fn(c, a, b)
+	case func(*Calculator, Value, Value, Value):
+		z, y, x := c.PopValue(), c.PopValue(), c.PopValue()
+		fn(c, x, y, z)
 	case func(*Calculator, Num, Num) Num:
 		b, a := c.Pop(), c.Pop()
 		c.Push(fn(c, a, b))
+	case func(*Calculator, Number, Number) Number:
+		b, a := c.PopValue(), c.PopValue()
+		c.PushValue(fn(c, a.(Number), b.(Number)))
+	case func(*Calculator, Value, Value) Value:
+		b, a := c.PopValue(), c.PopValue()
+		c.PushValue(fn(c, a, b))
+	case func(*Calculator, Num, Num, Num) Value:
+		z, y, x := c.Pop(), c.Pop(), c.Pop()
+		c.PushValue(fn(c, x, y, z))
 	}
 }
@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+//
+// Complex numbers, like 3+4i or 5∠53.13 (polar, in the current angle mode).
+// Complex results with no imaginary part go back to being Nums. In COMPLEX
+// mode SQRT and LN of negative numbers give complex results too.
+//
+
+type Complex struct {
+	Re, Im Num
+}
+
+// the polar angle symbol, as in 5∠53.13
+const angleSign = "∠"
+
+const complexNum = `(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?`
+
+var (
+	complexRe      = regexp.MustCompile(`^([+-]?` + complexNum + `)([+-]` + complexNum + `)?i$`)
+	complexPolarRe = regexp.MustCompile(`^([+-]?` + complexNum + `)` + angleSign + `([+-]?` + complexNum + `)$`)
+)
+
+// a Complex, or a Num if there's no imaginary part
+func ComplexValue(re, im Num) Number {
+	if im.IsZero() {
+		return re
+	}
+	return Complex{Re: re, Im: im}
+}
+
+// from polar, theta in radians
+func Polar(r, theta Num) Complex {
+	return Complex{Re: r.Mul(theta.Cos()), Im: r.Mul(theta.Sin())}
+}
+
+// parse "3+4i", "-2.5i" or "1e3-2i". Polar needs the angle mode, see
+// Calculator.Parse
+func ParseComplex(s string) (Value, error) {
+	m := complexRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, errors.New("invalid complex number")
+	}
+	re, im := m[1], m[2]
+	if im == "" {
+		re, im = "0", re // just "4i"
+	}
+	return ComplexValue(lo.Must(decimal.NewFromString(re)), lo.Must(decimal.NewFromString(im))), nil
+}
+
+// is this value complex?
+func IsComplex(v Value) bool {
+	_, ok := v.(Complex)
+	return ok
+}
+
+// convert a number to Complex
+func toComplex(v Value) Complex {
+	if z, ok := v.(Complex); ok {
+		return z
+	}
+	return Complex{Re: ToNum(v)}
+}
+
+func (z Complex) String() string {
+	if z.Re.IsZero() {
+		return z.Im.String() + "i"
+	}
+	return z.Re.String() + lo.Ternary(z.Im.IsNegative(), "", "+") + z.Im.String() + "i"
+}
+
+func (z Complex) IsZero() bool {
+	return z.Re.IsZero() && z.Im.IsZero()
+}
+
+//
+// arithmetic
+//
+
+func (z Complex) Add(w Complex) Complex {
+	return Complex{Re: z.Re.Add(w.Re), Im: z.Im.Add(w.Im)}
+}
+
+func (z Complex) Sub(w Complex) Complex {
+	return Complex{Re: z.Re.Sub(w.Re), Im: z.Im.Sub(w.Im)}
+}
+
+func (z Complex) Mul(w Complex) Complex {
+	return Complex{
+		Re: z.Re.Mul(w.Re).Sub(z.Im.Mul(w.Im)),
+		Im: z.Re.Mul(w.Im).Add(z.Im.Mul(w.Re)),
+	}
+}
+
+func (z Complex) Div(w Complex, prec int) Complex {
+	d := w.Re.Mul(w.Re).Add(w.Im.Mul(w.Im))
+	return Complex{
+		Re: Div(z.Re.Mul(w.Re).Add(z.Im.Mul(w.Im)), d, prec),
+		Im: Div(z.Im.Mul(w.Re).Sub(z.Re.Mul(w.Im)), d, prec),
+	}
+}
+
+func (z Complex) Neg() Complex {
+	return Complex{Re: z.Re.Neg(), Im: z.Im.Neg()}
+}
+
+func (z Complex) Conj() Complex {
+	return Complex{Re: z.Re, Im: z.Im.Neg()}
+}
+
+// |z|
+func (z Complex) Abs(prec int) Num {
+	return Pow(z.Re.Mul(z.Re).Add(z.Im.Mul(z.Im)), Half, prec)
+}
+
+// the angle of z, in radians from -pi to pi
+func (z Complex) Arg(prec int) Num {
+	return Atan2(z.Im, z.Re, prec)
+}
+
+// principal square root
+func (z Complex) Sqrt(prec int) Complex {
+	r := z.Abs(prec)
+	re := Pow(Div(r.Add(z.Re), Two, prec), Half, prec)
+	im := Pow(Div(r.Sub(z.Re), Two, prec), Half, prec)
+	if z.Im.IsNegative() {
+		im = im.Neg()
+	}
+	return Complex{Re: re, Im: im}
+}
+
+// principal natural log
+func (z Complex) Ln(prec int) Complex {
+	return Complex{Re: Ln(z.Abs(prec), prec), Im: z.Arg(prec)}
+}
+
+func (z Complex) Exp(prec int) Complex {
+	return Polar(lo.Must(z.Re.ExpTaylor(int32(prec+guardDigits))), z.Im) //nolint:gosec
+}
+
+// z ^ w. Integer powers are multiplied out, so (1+i)^2 is exactly 2i
+func (z Complex) Pow(w Complex, prec int) Complex {
+	if w.Im.IsZero() && w.Re.IsInteger() && w.Re.Abs().IntPart() <= maxExactPower {
+		result, base := Complex{Re: One}, z
+		for n := w.Re.Abs().IntPart(); n > 0; n >>= 1 {
+			if n&1 == 1 {
+				result = result.Mul(base)
+			}
+			base = base.Mul(base)
+		}
+		if w.Re.IsNegative() {
+			result = Complex{Re: One}.Div(result, prec)
+		}
+		return result
+	}
+	if z.Re.IsZero() && z.Im.IsZero() {
+		return z
+	}
+	return w.Mul(z.Ln(prec)).Exp(prec)
+}
+
+//
+// calculator
+//
+
+// parse a value, including polar complex numbers like 5∠53.13 in the current
+// angle mode
+func (c *Calculator) Parse(s string) (Value, error) {
+	return c.ParseRadix(s, c.radix)
+}
+
+// like Parse, but integers are read in radix instead of the current radix
+func (c *Calculator) ParseRadix(s string, radix Radix) (Value, error) {
+	if m := complexPolarRe.FindStringSubmatch(s); m != nil {
+		r, theta := lo.Must(decimal.NewFromString(m[1])), lo.Must(decimal.NewFromString(m[2]))
+		return Polar(r, c.toRadians(theta)), nil
+	}
+	return ParseValue(s, radix)
+}
+
+// format rectangular or polar, using the current display mode for the parts
+func (c *Calculator) formatComplex(z Complex) string {
+	if c.polar {
+		r, theta := z.Abs(c.precision), c.fromRadians(z.Arg(c.precision))
+		return c.formatPart(r) + angleSign + c.formatPart(theta)
+	}
+	if z.Re.IsZero() {
+		return c.formatPart(z.Im) + "i"
+	}
+	im := c.formatPart(z.Im.Abs())
+	return c.formatPart(z.Re) + lo.Ternary(z.Im.IsNegative(), "-", "+") + im + "i"
+}
+
+func (c *Calculator) formatPart(x Num) string {
+	return c.formatDisplay(NormalizePrec(x, c.precision))
+}
+
+// sqrt or ln of a negative number in COMPLEX mode?
+func (c *Calculator) wantsComplex(a Value) bool {
+	return IsComplex(a) || (c.complex && ToNum(a).IsNegative())
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseComplex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"3+4i", "3+4i"},
+		{"3-4i", "3-4i"},
+		{"-2.5i", "-2.5i"},
+		{"1e3+.5i", "1000+0.5i"},
+		{"7+0i", "7"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			v, err := ParseValue(tc.input, Dec)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, v.String())
+		})
+	}
+
+	for _, s := range []string{"i", "3+i", "3+4j", "3++4i", "3+4i5"} {
+		_, err := ParseComplex(s)
+		assert.Error(t, err, s)
+	}
+
+	// polar uses the angle mode
+	c := NewCalculator()
+	assert.NoError(t, c.RunTokens([]string{"2∠90", "rad", "1∠3.14159265358979"}))
+	assert.Equal(t, []string{"2i", "-1"}, c.GetStackString())
+}
+
+func TestComplex(t *testing.T) {
+	c := NewCalculator()
+	run := func(tokens ...string) []string {
+		c.Clear()
+		assert.NoError(t, c.RunTokens(tokens))
+		return c.GetStackString()
+	}
+
+	assert.Equal(t, []string{"4+6i"}, run("1+2i", "3+4i", "+"))
+	assert.Equal(t, []string{"-2-2i"}, run("1+2i", "3+4i", "-"))
+	assert.Equal(t, []string{"-5+10i"}, run("1+2i", "3+4i", "*"))
+	assert.Equal(t, []string{"0.44+0.08i"}, run("1+2i", "3+4i", "/"))
+	assert.Equal(t, []string{"4+2i"}, run("1+2i", "3", "+"))
+	assert.Equal(t, []string{"2"}, run("1+1i", "1-1i", "*"))
+	assert.Equal(t, []string{"2i"}, run("1+1i", "2", "^"))
+	assert.Equal(t, []string{"-0.5i"}, run("1+1i", "-2", "^"))
+	assert.Equal(t, []string{"0.2078795764"}, run("1i", "1i", "^"))
+	assert.Equal(t, []string{"1+2i"}, run("-3+4i", "sqrt"))
+	assert.Equal(t, []string{"1.6094379124+0.927295218i"}, run("3+4i", "ln"))
+	assert.Equal(t, []string{"5", "-3-4i", "-3+4i"}, run("3+4i", "abs", "-3+4i", "conj", "3+4i", "neg", "conj"))
+	assert.Equal(t, []string{"0.6-0.8i"}, run("3+4i", "inv", "5", "*"))
+
+	// splitting and joining
+	assert.Equal(t, []string{"3", "4"}, run("3+4i", "c->r"))
+	assert.Equal(t, []string{"3+4i"}, run("3", "4", "r->c"))
+	assert.Equal(t, []string{"5", "53.1301023542"}, run("3+4i", "c->p"))
+	assert.Equal(t, []string{"53.1301023542"}, run("3+4i", "arg"))
+	assert.Equal(t, []string{"3+4i"}, run("5", "53.1301023542", "p->c"))
+
+	// real commands don't take complex
+	c.Clear()
+	c.PushValue(Complex{Re: One, Im: One})
+	assert.EqualError(t, c.Run("SIN"), "not a number")
+	assert.EqualError(t, c.Run("LOG"), "not a number")
+	assert.Equal(t, []string{"3", "0"}, run("3", "c->r"))
+	c.Clear()
+	c.PushValue(NewColorRGB(1, 2, 3))
+	assert.EqualError(t, c.Run("C->R"), "not a number")
+}
+
+func TestComplexMode(t *testing.T) {
+	c := NewCalculator()
+
+	// off, negatives are rejected
+	c.PushInt(-4)
+	assert.EqualError(t, c.Run("SQRT"), "not positive")
+	assert.EqualError(t, c.Run("LN"), "not positive")
+	assert.EqualError(t, c.RunTokens([]string{"8", "neg", "0.5", "^"}), "POW: fractional power of a negative")
+	c.Clear()
+
+	// zero
+	for _, tokens := range [][]string{{"0", "inv"}, {"0", "-1", "pow"}, {"0", "-1+1i", "pow"}, {"0_m", "-2", "pow"}} {
+		assert.EqualError(t, c.RunTokens(tokens), strings.ToUpper(tokens[len(tokens)-1])+": divide by zero", tokens)
+		c.Clear()
+	}
+	assert.EqualError(t, c.RunTokens([]string{"0", "0", "^"}), "POW: 0 ^ 0 is undefined")
+	c.Clear()
+	c.PushInt(-4)
+
+	// on, negatives go complex
+	assert.NoError(t, c.Run("COMPLEX"))
+	assert.Contains(t, c.GetModes(), "COMPLEX")
+	assert.NoError(t, c.RunTokens([]string{"sqrt", "-1", "ln", "-8", "1/3", "^"}))
+	assert.Equal(t, []string{"2i", "3.1415926536i", "1+1.7320508076i"}, c.GetStackString())
+	assert.Equal(t, "sqrt(-4) = 2i", c.GetHistory()[0])
+	c.PushInt(0)
+	assert.EqualError(t, c.Run("LN"), "not positive")
+	c.PushValue(Complex{Re: One, Im: One})
+	c.PushInt(0)
+	assert.EqualError(t, c.Run("DIV"), "divide by zero")
+
+	// positives are still real
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"4", "sqrt"}))
+	assert.Equal(t, []string{"2"}, c.GetStackString())
+}
+
+func TestComplexFormat(t *testing.T) {
+	c := NewCalculator()
+	z := Complex{Re: One.Neg(), Im: One}
+	assert.Equal(t, "-1+1i", c.Format(z))
+	assert.Equal(t, "-1-1i", c.Format(z.Conj()))
+	assert.NoError(t, c.Run("POLAR"))
+	assert.Equal(t, "1.4142135624∠135", c.Format(z))
+	c.SetAngle(Rad)
+	assert.Equal(t, "1.4142135624∠-2.3561944902", c.Format(z.Conj()))
+	assert.Contains(t, c.GetModes(), "POLAR")
+
+	c.SetPolar(false)
+	c.SetDisplayMode(Fix, 2)
+	assert.Equal(t, "-1.00+1.00i", c.Format(z))
+}
@@ -0,0 +1,301 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+//
+// Dates like 2026-10-18 or 2026-10-18T14:30, and durations like 3d4h. Dates
+// are wall clock times with no time zone, so adding 1d is always a calendar
+// day. The local time zone only matters for NOW, TODAY and unix timestamps.
+//
+
+type Date struct {
+	Time time.Time // always UTC
+}
+
+type Duration time.Duration
+
+const day = 24 * time.Hour
+
+var (
+	dateRe            = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(T\d{2}:\d{2}(:\d{2}(\.\d+)?)?)?$`)
+	durationRe        = regexp.MustCompile(`^([+-]?)(?:(\d+)w)?(?:(\d+)d)?(?:(\d+)h)?(?:(\d+)m)?(?:(\d+(?:\.\d+)?)s)?$`)
+	partialDateRe     = regexp.MustCompile(`^\d{4}-(\d{1,2}(-(\d{1,2}(T(\d{1,2}(:(\d{1,2}(:(\d{1,2}(\.\d*)?)?)?)?)?)?)?)?)?)?$`)
+	partialDurationRe = regexp.MustCompile(`^[+-]?(\d+w)?(\d+d)?(\d+h)?(\d+m)?(\d+(\.\d*)?s?)?$`)
+)
+
+// dates have to fit in YYYY-MM-DD, and durations in int64 nanoseconds
+var (
+	minDate     = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxDate     = time.Date(9999, 12, 31, 23, 59, 59, 999999999, time.UTC)
+	maxDuration = decimal.NewFromInt(math.MaxInt64)
+)
+
+//
+// dates
+//
+
+// the wall clock time of t, as a Date
+func DateOf(t time.Time) Date {
+	return Date{Time: time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)}
+}
+
+// parse "2026-10-18", "2026-10-18T14:30" or "2026-10-18T14:30:05"
+func ParseDate(s string) (Value, error) {
+	if !dateRe.MatchString(s) {
+		return nil, errors.New("invalid date")
+	}
+	layout := "2006-01-02T15:04:05"
+	if len(s) < len(layout) {
+		layout = layout[:len(s)]
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return nil, errors.New("invalid date")
+	}
+	return Date{Time: t}, nil
+}
+
+// is this the start of a date? Used to decide if a "-" belongs in the text
+// input, so it needs the first "-" ("2026" is a number)
+func IsPartialDate(s string) bool {
+	return partialDateRe.MatchString(s)
+}
+
+// is this value a Date?
+func IsDate(v Value) bool {
+	_, ok := v.(Date)
+	return ok
+}
+
+func (d Date) String() string {
+	t := d.Time
+	switch {
+	case t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0:
+		return t.Format("2006-01-02")
+	case t.Second() == 0 && t.Nanosecond() == 0:
+		return t.Format("2006-01-02T15:04")
+	}
+	return t.Format("2006-01-02T15:04:05.999999999")
+}
+
+func (d Date) Add(dur Duration) Date {
+	return Date{Time: d.Time.Add(time.Duration(dur))}
+}
+
+func (d Date) Sub(e Date) Duration {
+	return Duration(d.Time.Sub(e.Time))
+}
+
+// seconds since the epoch, reading the wall clock in the local time zone
+func (d Date) Unix() Num {
+	t := d.Time
+	local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+	return decimal.NewFromInt(local.Unix()).Add(decimal.New(int64(local.Nanosecond()), -9))
+}
+
+// from seconds since the epoch, in the local time zone. Seconds and nanos
+// separately, since UnixNano overflows past 2262. See validUnix
+func UnixDate(x Num) Date {
+	sec := x.Floor()
+	return DateOf(time.Unix(sec.IntPart(), x.Sub(sec).Shift(9).Round(0).IntPart()).In(time.Local))
+}
+
+//
+// durations
+//
+
+// parse "3d4h", "1h30m", "2w" or "-1.5s"
+func ParseDuration(s string) (Value, error) {
+	m := durationRe.FindStringSubmatch(s)
+	if m == nil || strings.TrimLeft(s, "+-") == "" {
+		return nil, errors.New("invalid duration")
+	}
+	// add up in decimal, to catch overflow
+	var d Num
+	for ii, unit := range []time.Duration{7 * day, day, time.Hour, time.Minute} {
+		if m[ii+2] != "" {
+			d = d.Add(lo.Must(decimal.NewFromString(m[ii+2])).Mul(decimal.NewFromInt(int64(unit))))
+		}
+	}
+	if m[6] != "" {
+		d = d.Add(lo.Must(decimal.NewFromString(m[6])).Shift(9).Truncate(0))
+	}
+	if d.GreaterThan(maxDuration) {
+		return nil, errors.New("duration too large")
+	}
+	if m[1] == "-" {
+		d = d.Neg()
+	}
+	return Duration(d.IntPart()), nil
+}
+
+// is this the start of a duration? Used to decide if a letter belongs in the
+// text input, like the "d" in "3d"
+func IsPartialDuration(s string) bool {
+	return partialDurationRe.MatchString(s)
+}
+
+// is this value a Duration?
+func IsDuration(v Value) bool {
+	_, ok := v.(Duration)
+	return ok
+}
+
+// is this value a Date or a Duration?
+func IsTime(v Value) bool {
+	return IsDate(v) || IsDuration(v)
+}
+
+// like 3d4h or 1h30m5.5s
+func (d Duration) String() string {
+	if d == 0 {
+		return "0s"
+	}
+	var sb strings.Builder
+	if d < 0 {
+		sb.WriteString("-")
+		d = -d
+	}
+	x := time.Duration(d)
+	for _, unit := range []struct {
+		size time.Duration
+		name string
+	}{{day, "d"}, {time.Hour, "h"}, {time.Minute, "m"}} {
+		if n := x / unit.size; n > 0 {
+			fmt.Fprintf(&sb, "%d%s", n, unit.name)
+		}
+		x %= unit.size
+	}
+	if x > 0 {
+		sb.WriteString(decimal.New(int64(x), -9).String() + "s")
+	}
+	return sb.String()
+}
+
+func (d Duration) Mul(x Num) Duration {
+	return Duration(decimal.NewFromInt(int64(d)).Mul(x).Round(0).IntPart())
+}
+
+//
+// arithmetic, see dateError for what's allowed
+//
+
+// which kinds of values can be combined, and how
+var dateMath = map[string]bool{
+	"date + duration":     true,
+	"duration + date":     true,
+	"duration + duration": true,
+	"date - date":         true,
+	"date - duration":     true,
+	"duration - duration": true,
+	"duration * number":   true,
+	"number * duration":   true,
+	"duration / number":   true,
+	"duration / duration": true,
+}
+
+func dateKind(v Value) string {
+	switch {
+	case IsDate(v):
+		return "date"
+	case IsDuration(v):
+		return "duration"
+	case IsNum(v):
+		return "number"
+	}
+	return "other"
+}
+
+// can a and b be combined with op? Only matters if one is a Date or Duration
+func dateError(a, b Value, op string) error {
+	if !IsTime(a) && !IsTime(b) {
+		return nil
+	}
+	if s := dateKind(a) + " " + op + " " + dateKind(b); !dateMath[s] {
+		return errors.New("can't do " + s)
+	}
+	return dateRangeError(a, b, op)
+}
+
+// does the result of a op b fit? Worked out in decimal nanoseconds, since
+// time.Duration silently overflows
+func dateRangeError(a, b Value, op string) error {
+	nanos := func(v Value) Num {
+		switch v := v.(type) {
+		case Date:
+			return decimal.NewFromInt(v.Time.Unix()).Shift(9).Add(decimal.NewFromInt(int64(v.Time.Nanosecond())))
+		case Duration:
+			return decimal.NewFromInt(int64(v))
+		}
+		return ToNum(v)
+	}
+	x, y := nanos(a), nanos(b)
+	var result Num
+	switch op {
+	case "+":
+		result = x.Add(y)
+	case "-":
+		result = x.Sub(y)
+	case "*":
+		result = x.Mul(y)
+	case "/":
+		if IsDuration(b) || y.IsZero() {
+			return nil // a number, or divide by zero
+		}
+		result = x.DivRound(y, 0)
+	}
+	if IsDate(a) != IsDate(b) {
+		if result.LessThan(nanos(Date{Time: minDate})) || result.GreaterThan(nanos(Date{Time: maxDate})) {
+			return errors.New("date out of range")
+		}
+		return nil
+	}
+	if result.Abs().GreaterThan(maxDuration) {
+		return errors.New("duration too large")
+	}
+	return nil
+}
+
+func dateAdd(a, b Value) Value {
+	if IsDate(b) {
+		a, b = b, a
+	}
+	if d, ok := a.(Date); ok {
+		return d.Add(b.(Duration))
+	}
+	return a.(Duration) + b.(Duration)
+}
+
+func dateSub(a, b Value) Value {
+	switch b := b.(type) {
+	case Date:
+		return a.(Date).Sub(b)
+	case Duration:
+		return dateAdd(a, -b)
+	}
+	panic("unreachable")
+}
+
+func dateMul(a, b Value) Value {
+	if d, ok := a.(Duration); ok {
+		return d.Mul(ToNum(b))
+	}
+	return b.(Duration).Mul(ToNum(a))
+}
+
+func dateDiv(a, b Value, prec int) Value {
+	if d, ok := b.(Duration); ok {
+		return Div(decimal.NewFromInt(int64(a.(Duration))), decimal.NewFromInt(int64(d)), prec)
+	}
+	return Duration(decimal.NewFromInt(int64(a.(Duration))).DivRound(ToNum(b), 0).IntPart())
+}
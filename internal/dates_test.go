@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2026-10-18", "2026-10-18"},
+		{"2026-10-18T14:30", "2026-10-18T14:30"},
+		{"2026-10-18T14:30:05", "2026-10-18T14:30:05"},
+		{"2026-10-18T00:00", "2026-10-18"},
+		{"3d4h", "3d4h"},
+		{"2w", "14d"},
+		{"90m", "1h30m"},
+		{"1.5s", "1.5s"},
+		{"-1d12h", "-1d12h"},
+		{"0s", "0s"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			v, err := ParseValue(tc.input, Dec)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, v.String())
+		})
+	}
+
+	for _, s := range []string{"2026-13-01", "2026-02-30", "2026-1-1", "2026-10-18T25:00"} {
+		_, err := ParseValue(s, Dec)
+		assert.Error(t, err, s)
+	}
+	for _, s := range []string{"", "-", "3h4d", "1.5h"} {
+		_, err := ParseDuration(s)
+		assert.Error(t, err, s)
+	}
+
+	// too large
+	for _, s := range []string{"200000d", "16000w", "9223372037s"} {
+		_, err := ParseDuration(s)
+		assert.EqualError(t, err, "duration too large", s)
+	}
+
+	// partial
+	for _, s := range []string{"2026-", "2026-10-1", "2026-10-18T", "2026-10-18T14:3", "3d", "3d4", "1h30m", "1.5s"} {
+		assert.True(t, IsPartialValue(s, Dec), s)
+	}
+	for _, s := range []string{"2026-10-18T14:30:05.5x", "3h4d", "3d4h5w"} {
+		assert.False(t, IsPartialValue(s, Dec), s)
+	}
+	assert.False(t, IsPartialValue("3h", Hex))
+
+	// durations are only for decimal
+	v, err := ParseValue("3d", Hex)
+	assert.NoError(t, err)
+	assert.Equal(t, "61", v.String())
+}
+
+func TestDates(t *testing.T) {
+	c := NewCalculator()
+	run := func(tokens ...string) []string {
+		c.Clear()
+		assert.NoError(t, c.RunTokens(tokens))
+		return c.GetStackString()
+	}
+
+	assert.Equal(t, []string{"2026-11-17"}, run("2026-10-18", "30d", "+"))
+	assert.Equal(t, []string{"2026-11-17"}, run("30d", "2026-10-18", "+"))
+	assert.Equal(t, []string{"2026-10-17T20:00"}, run("2026-10-18", "4h", "-"))
+	assert.Equal(t, []string{"73d"}, run("2026-12-31", "2026-10-19", "-"))
+	assert.Equal(t, []string{"-1d"}, run("2026-03-01", "2026-03-02", "-"))
+	assert.Equal(t, []string{"3d4h30m"}, run("3d4h", "30m", "+"))
+	assert.Equal(t, []string{"1d"}, run("8h", "3", "*"))
+	assert.Equal(t, []string{"3h20m"}, run("10h", "3", "/"))
+	assert.Equal(t, []string{"36"}, run("1d12h", "1h", "/"))
+	assert.Equal(t, []string{"2028-02-29T12:00"}, run("2028-02-28T12:00", "1d", "+"))
+
+	// history
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"2026-10-18", "30d", "+"}))
+	assert.Equal(t, []string{"2026-10-18 + 30d = 2026-11-17"}, c.GetHistory())
+
+	// errors
+	for _, tokens := range [][]string{
+		{"2026-10-18", "2026-10-18", "+"},
+		{"2026-10-18", "1", "+"},
+		{"1d", "2026-10-18", "-"},
+		{"1d", "1d", "*"},
+		{"1", "1d", "/"},
+		{"1d", "1_m", "+"},
+	} {
+		c.Clear()
+		assert.Error(t, c.RunTokens(tokens), tokens)
+	}
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"2026-10-18", "1", "+"}), "ADD: can't do date + number")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1d", "0s", "/"}), "DIV: divide by zero")
+
+	// overflow
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"3d", "100000", "*"}), "MUL: duration too large")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"100000d", "100000d", "+"}), "ADD: duration too large")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"100000d", "0.001", "/"}), "DIV: duration too large")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"9999-12-31", "1d", "+"}), "ADD: date out of range")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"2026-10-18", "1000-01-01", "-"}), "SUB: duration too large")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1d", "2", "^"}), "POW: not a number")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1d", "sqrt"}), "SQRT: not a number")
+}
+
+func TestDateCommands(t *testing.T) {
+	c := NewCalculator()
+
+	// unix timestamps are in the local time zone
+	epoch := time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local).Unix()
+	assert.NoError(t, c.RunTokens([]string{"2026-10-18", "->unix"}))
+	assert.Equal(t, epoch, c.Peek().IntPart())
+	assert.NoError(t, c.RunTokens([]string{"3600", "+", "->date"}))
+	assert.Equal(t, "2026-10-18T01:00", c.PeekValue().String())
+	assert.EqualError(t, c.Run("->DATE"), "not a number")
+
+	// the same range as date math, past where UnixNano overflows
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"1e10", "->date", "->unix"}))
+	assert.Equal(t, "10000000000", c.PopValue().String())
+	for _, x := range []string{"253402300800", "-62135596801", "1e20"} {
+		c.Push(decimal.RequireFromString(x))
+		assert.EqualError(t, c.Run("->DATE"), "date out of range", x)
+		c.Clear()
+	}
+	c.Push(decimal.NewFromInt(253402300799))
+	assert.NoError(t, c.Run("->DATE"))
+	c.Clear()
+	c.PushInt(1)
+	assert.EqualError(t, c.Run("->UNIX"), "not a date")
+
+	// now and today
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"today", "now"}))
+	today, now := c.PeekN(1).(Date), c.PeekN(0).(Date)
+	assert.Equal(t, time.Now().Format("2006-01-02"), today.String())
+	assert.True(t, now.Sub(today) >= 0 && now.Sub(today) < Duration(day))
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+//
+// Display formats for Nums, see DisplayMode. These only change rendering - the
+// stack keeps full precision.
+//
+
+// FIX/SCI/ENG/SI take 0..maxDigits places
+const maxDigits = 20
+
+// SI prefixes from 10^-24 to 10^24, in steps of 10^3
+var siPrefixes = []string{"y", "z", "a", "f", "p", "n", "µ", "m", "", "k", "M", "G", "T", "P", "E", "Z", "Y"}
+
+// format x in the current display mode
+func (c *Calculator) formatDisplay(x Num) string {
+	places := int32(c.digits) //nolint:gosec
+	var s string
+	switch c.display {
+	case Std:
+		s = x.String()
+	case Fix:
+		s = x.StringFixed(places)
+	case Sci:
+		m, e := scientific(x, places, 1)
+		s = m.StringFixed(places) + "e" + strconv.Itoa(e)
+	case Eng:
+		m, e := scientific(x, places, 3)
+		s = m.StringFixed(places) + "e" + strconv.Itoa(e)
+	case SI:
+		m, e := scientific(x, places, 3)
+		if ii := e/3 + 8; ii >= 0 && ii < len(siPrefixes) {
+			s = m.StringFixed(places) + siPrefixes[ii]
+		} else {
+			s = m.StringFixed(places) + "e" + strconv.Itoa(e)
+		}
+	}
+	return c.separate(s)
+}
+
+// Split x into a mantissa and an exponent, where the exponent is a multiple of
+// step. The mantissa is rounded to places.
+func scientific(x Num, places int32, step int) (Num, int) {
+	if x.IsZero() {
+		return x, 0
+	}
+	// x = coef * 10^exp, so the leading digit is at len(coef) - 1 + exp
+	e := len(new(big.Int).Abs(x.Coefficient()).String()) - 1 + int(x.Exponent())
+	e -= ((e % step) + step) % step
+	m := x.Shift(int32(-e)).Round(places) //nolint:gosec
+	if m.Abs().GreaterThanOrEqual(decimal.New(1, int32(step))) {
+		// rounding carried, like 9.99 => 10.0
+		e += step
+		m = x.Shift(int32(-e)).Round(places) //nolint:gosec
+	}
+	return m, e
+}
+
+// use the locale's decimal point, and add thousands separators if grouping,
+// like 1234567.5 => 1,234,567.5
+func (c *Calculator) separate(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	end := strings.IndexFunc(s, func(r rune) bool { return r != '.' && !unicode.IsDigit(r) })
+	if end == -1 {
+		end = len(s)
+	}
+	whole, frac, hasFrac := strings.Cut(s[:end], ".")
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for ii, r := range whole {
+		if c.grouping && ii > 0 && (len(whole)-ii)%3 == 0 {
+			sb.WriteString(c.thousands)
+		}
+		sb.WriteRune(r)
+	}
+	if hasFrac {
+		sb.WriteString(c.point + frac)
+	}
+	sb.WriteString(s[end:])
+	return sb.String()
+}
+
+// thousands and decimal separators for a locale like "de_DE.UTF-8"
+func LocaleSeparators(locale string) (string, string) {
+	lang, region, _ := strings.Cut(strings.SplitN(locale, ".", 2)[0], "_")
+	switch {
+	case region == "CH" || region == "LI":
+		return "'", "."
+	case slices.Contains([]string{"da", "de", "el", "es", "id", "it", "nl", "pt", "ro", "tr"}, lang):
+		return ".", ","
+	case slices.Contains([]string{"cs", "fi", "fr", "hu", "nb", "no", "pl", "ru", "sk", "sv", "uk"}, lang):
+		return " ", ","
+	}
+	return ",", "."
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDisplay(t *testing.T) {
+	c := NewCalculator()
+	x := decimal.RequireFromString("1234567890.123")
+	tests := []struct {
+		mode   DisplayMode
+		digits int
+		x      string
+		want   string
+	}{
+		{Std, 0, "1234567890.123", "1234567890.123"},
+		{Fix, 2, "1234567890.123", "1234567890.12"},
+		{Fix, 0, "-2.5", "-3"},
+		{Sci, 3, "1234567890.123", "1.235e9"},
+		{Sci, 2, "-0.000123", "-1.23e-4"},
+		{Sci, 2, "9.999", "1.00e1"},
+		{Sci, 2, "0", "0.00e0"},
+		{Eng, 2, "1234567890.123", "1.23e9"},
+		{Eng, 2, "12345", "12.35e3"},
+		{Eng, 1, "0.000123", "123.0e-6"},
+		{Eng, 1, "999.96", "1.0e3"},
+		{SI, 2, "4700", "4.70k"},
+		{SI, 1, "0.0000047", "4.7µ"},
+		{SI, 0, "1500000", "2M"},
+		{SI, 0, "0.5", "500m"},
+		{SI, 1, "1e30", "1.0e30"},
+	}
+	for _, tt := range tests {
+		c.SetDisplayMode(tt.mode, tt.digits)
+		assert.Equal(t, tt.want, c.Format(decimal.RequireFromString(tt.x)), "%s %d %s", tt.mode, tt.digits, tt.x)
+	}
+
+	// grouping & locales
+	c.SetDisplayMode(Fix, 2)
+	c.SetGrouping(true)
+	assert.Equal(t, "1,234,567,890.12", c.Format(x))
+	assert.Equal(t, "-123.00", c.Format(decimal.NewFromInt(-123)))
+	assert.Equal(t, "-1,000.00", c.Format(decimal.NewFromInt(-1000)))
+	c.SetLocale("de_DE.UTF-8")
+	assert.Equal(t, "1.234.567.890,12", c.Format(x))
+	c.SetLocale("fr_FR")
+	assert.Equal(t, "1 234 567 890,12", c.Format(x))
+	c.SetLocale("de_CH.UTF-8")
+	assert.Equal(t, "1'234'567'890.12", c.Format(x))
+
+	// the decimal point applies without grouping too
+	c.SetGrouping(false)
+	c.SetLocale("de_DE.UTF-8")
+	assert.Equal(t, "1234567890,12", c.Format(x))
+	assert.Equal(t, "-123,00", c.Format(decimal.NewFromInt(-123)))
+	c.SetLocale("de_CH.UTF-8")
+	c.SetGrouping(true)
+	c.SetDisplayMode(SI, 1)
+	assert.Equal(t, "1.2G", c.Format(x))
+
+	// the stack keeps full precision
+	c.Push(x)
+	assert.Equal(t, []string{"1234567890.123"}, c.GetStackString())
+}
+
+func TestDisplayCommands(t *testing.T) {
+	c := NewCalculator()
+	assert.NoError(t, c.RunTokens([]string{"3", "fix", "1234.5678", "group"}))
+	assert.Equal(t, []string{"DEG", "FIX 3", "1,000"}, c.GetModes())
+	assert.Equal(t, "1: 1,234.568", c.GetDisplay()[StackSize-1])
+	assert.NoError(t, c.RunTokens([]string{"std", "group"}))
+	assert.Equal(t, "1: 1234.5678", c.GetDisplay()[StackSize-1])
+
+	c.PushInt(21)
+	assert.EqualError(t, c.Run("SCI"), "digits must be 0 to 20")
+	c.PushFloat64(1.5)
+	assert.EqualError(t, c.Run("ENG"), "digits must be 0 to 20")
+}
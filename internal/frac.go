@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+
+	"github.com/shopspring/decimal"
+)
+
+//
+// Exact fractions, like 1/3. In EXACT mode division (and negative powers) of
+// Nums gives a Frac instead of rounding, and arithmetic on Fracs stays exact.
+// A Frac that turns out to be an integer goes back to being a Num.
+//
+
+type Frac struct {
+	r *big.Rat
+}
+
+// don't try to raise fractions to enormous powers
+const maxExactPower = 1000
+
+var fracRe = regexp.MustCompile(`^[+-]?\d+/\d+$`)
+
+// a Frac, or a Num if r is an integer
+func RatValue(r *big.Rat) Number {
+	if r.IsInt() {
+		return decimal.NewFromBigInt(r.Num(), 0)
+	}
+	return Frac{r: r}
+}
+
+// parse "1/3" or "-22/7"
+func ParseFrac(s string) (Value, error) {
+	if !fracRe.MatchString(s) {
+		return nil, errors.New("invalid fraction")
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, errors.New("invalid fraction")
+	}
+	return RatValue(r), nil
+}
+
+func (f Frac) Rat() *big.Rat {
+	return f.r
+}
+
+// to a Num, with plenty of digits. PushValue rounds to the working precision.
+func (f Frac) Num() Num {
+	return decimal.NewFromBigRat(f.r, int32(MaxPrecision+guardDigits))
+}
+
+func (f Frac) String() string {
+	return f.r.RatString()
+}
+
+func (f Frac) IsZero() bool {
+	return f.r.Sign() == 0
+}
+
+// the value as a big.Rat, if it's a Num or a Frac
+func toRat(v Value) *big.Rat {
+	switch v := v.(type) {
+	case Frac:
+		return v.r
+	case Num:
+		return v.Rat()
+	}
+	return nil
+}
+
+// a ^ n, exactly
+func powRat(a *big.Rat, n int64) *big.Rat {
+	e := big.NewInt(n)
+	num, denom := new(big.Int).Set(a.Num()), new(big.Int).Set(a.Denom())
+	if n < 0 {
+		e.Neg(e)
+		num, denom = denom, num
+	}
+	num.Exp(num, e, nil)
+	denom.Exp(denom, e, nil)
+	return new(big.Rat).SetFrac(num, denom)
+}
+
+// the simplest fraction within epsilon(prec) of x, using continued fractions.
+// 3.1415926536 => 355/113
+func BestRat(x Num, prec int) *big.Rat {
+	target, eps := x.Rat(), epsilon(prec).Rat()
+	h0, h1 := big.NewInt(0), big.NewInt(1) // numerators
+	k0, k1 := big.NewInt(1), big.NewInt(0) // denominators
+	rest := new(big.Rat).Set(target)
+	for range 100 {
+		// next term, a = floor(rest)
+		a := new(big.Int).Div(rest.Num(), rest.Denom())
+		h0, h1 = h1, new(big.Int).Add(new(big.Int).Mul(a, h1), h0)
+		k0, k1 = k1, new(big.Int).Add(new(big.Int).Mul(a, k1), k0)
+
+		guess := new(big.Rat).SetFrac(h1, k1)
+		diff := new(big.Rat).Sub(guess, target)
+		if diff.Abs(diff).Cmp(eps) < 0 {
+			return guess
+		}
+		rest.Sub(rest, new(big.Rat).SetInt(a))
+		if rest.Sign() == 0 {
+			return guess
+		}
+		rest.Inv(rest)
+	}
+	return target
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFrac(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1/3", "1/3"},
+		{"-22/7", "-22/7"},
+		{"2/4", "1/2"},
+		{"6/3", "2"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			v, err := ParseValue(tc.input, Dec)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, v.String())
+		})
+	}
+
+	for _, s := range []string{"1/0", "1/", "/3", "1.5/2", "1/3/4"} {
+		_, err := ParseFrac(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestBestRat(t *testing.T) {
+	tests := []struct {
+		input    string
+		prec     int
+		expected string
+	}{
+		{"0.3333333333", Precision, "1/3"},
+		{"0.125", Precision, "1/8"},
+		{"-0.75", Precision, "-3/4"},
+		{"3.1415926536", Precision, "355/113"},
+		{"0.1428571429", Precision, "1/7"},
+		{"2", Precision, "2"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			r := BestRat(decimal.RequireFromString(tc.input), tc.prec)
+			assert.Equal(t, tc.expected, r.RatString())
+		})
+	}
+}
+
+func TestFracs(t *testing.T) {
+	c := NewCalculator()
+	run := func(tokens ...string) []string {
+		c.Clear()
+		assert.NoError(t, c.RunTokens(tokens))
+		return c.GetStackString()
+	}
+
+	// plain decimal mode rounds
+	assert.Equal(t, []string{"0.3333333333"}, run("1", "3", "/"))
+
+	// exact mode stays exact
+	assert.NoError(t, c.Run("EXACT"))
+	assert.Equal(t, []string{"1/3"}, run("1", "3", "/"))
+	assert.Equal(t, []string{"1"}, run("1", "3", "/", "3", "*"))
+	assert.Equal(t, []string{"5/6"}, run("1/3", "0.5", "+"))
+	assert.Equal(t, []string{"-1/6"}, run("1/3", "1/2", "-"))
+	assert.Equal(t, []string{"3/2"}, run("2/3", "inv"))
+	assert.Equal(t, []string{"1/9"}, run("3", "-2", "^"))
+	assert.Equal(t, []string{"8/27"}, run("2/3", "3", "^"))
+	assert.Equal(t, []string{"1/3", "1/3"}, run("-1/3", "neg", "dup", "abs"))
+	assert.Equal(t, []string{"2", "5"}, run("6", "3", "/", "10", "2", "/"))
+	assert.Contains(t, c.GetModes(), "EXACT")
+
+	// fracs still work with everything else
+	assert.Equal(t, []string{"0.5"}, run("1/4", "sqrt"))
+	assert.Equal(t, []string{"0.3333333333"}, run("1/3", "->num"))
+
+	// recipe scaling, 2/3 cup for 4 people => 6 people
+	assert.Equal(t, []string{"1"}, run("2/3", "4", "/", "6", "*"))
+
+	// ->Q
+	assert.NoError(t, c.Run("EXACT"))
+	assert.Equal(t, []string{"355/113"}, run("pi", "->q"))
+	assert.Equal(t, []string{"2/3"}, run("2", "3", "/", "->q"))
+	assert.Equal(t, []string{"7"}, run("7", "->q"))
+
+	// display as decimals
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"2/3"}))
+	assert.Equal(t, "2/3", c.Format(c.PeekValue()))
+	assert.NoError(t, c.Run("DECIMALS"))
+	assert.Equal(t, "0.6666666667", c.Format(c.PeekValue()))
+	assert.Equal(t, "2/3", c.PeekValue().String())
+	assert.Contains(t, c.GetModes(), "DECIMALS")
+
+	// history
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"1/2", "1/3", "+"}))
+	assert.Equal(t, []string{"1/2 + 1/3 = 5/6"}, c.GetHistory())
+}
@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+)
+
+//
+// fuzzy matching for the command palette, like "sq" => "SQRT"
+//
+
+// Score how well query matches s, case insensitive. Every rune in query must
+// appear in s, in order. Higher is better - consecutive runs and matches at
+// the start of a word score extra.
+func FuzzyScore(query, s string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	score, run, qi, prev := 0, 0, 0, ' '
+	for _, r := range strings.ToLower(s) {
+		if qi < len(q) && r == q[qi] {
+			qi++
+			run++
+			score += run
+			if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+				score += 3 // start of word
+			}
+		} else {
+			run = 0
+		}
+		prev = r
+	}
+	return score, qi == len(q)
+}
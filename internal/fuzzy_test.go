@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	_, ok := FuzzyScore("", "SQRT")
+	assert.True(t, ok)
+	_, ok = FuzzyScore("sqt", "SQRT")
+	assert.True(t, ok)
+	_, ok = FuzzyScore("tq", "SQRT")
+	assert.False(t, ok)
+
+	// prefix beats scattered, word starts beat the middle of words
+	sqrt, _ := FuzzyScore("sqr", "SQRT")
+	squeeze, _ := FuzzyScore("sqr", "squeezer")
+	assert.Greater(t, sqrt, squeeze)
+	log, _ := FuzzyScore("log", "log base 10")
+	ln, _ := FuzzyScore("log", "natural log")
+	assert.Greater(t, log, ln)
+}
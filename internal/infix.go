@@ -0,0 +1,235 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+//
+// Infix expressions like "(1 + sqrt(5)) / 2", compiled to RPN tokens with
+// shunting-yard and then run like anything else. Functions are commands that
+// take numbers and push one result, like sqrt or pow(2, 10). Bare names are
+// zero argument commands like pi, or registers. Numbers are always decimal,
+// even in HEX, so ff is a name and 0xff is a number.
+//
+
+var infixTokenRe = regexp.MustCompile(`^\s*(?:(0[xX][\da-fA-F]+|0[bBoO]\d+|(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?)|([\pL_][\pL\d_]*)|([-+*/^(),]))`)
+
+type infixOperator struct {
+	name       string
+	precedence int
+	right      bool
+}
+
+var infixOperators = map[string]infixOperator{
+	"+": {name: "ADD", precedence: 1},
+	"-": {name: "SUB", precedence: 1},
+	"*": {name: "MUL", precedence: 2},
+	"/": {name: "DIV", precedence: 2},
+	"^": {name: "POW", precedence: 4, right: true},
+}
+
+// unary minus binds tighter than * but looser than ^, so -2^2 is -4
+var infixNeg = infixOperator{name: NEG, precedence: 3, right: true}
+
+// Run an infix expression as a single undo step. History shows the
+// expression and the result, not each step along the way.
+func (c *Calculator) RunInfix(expr string) error {
+	expr = strings.TrimSpace(expr)
+	tokens, err := c.compileInfix(expr)
+	if err != nil {
+		return err
+	}
+	// numbers are decimal, see above
+	radix := c.radix
+	c.radix = Dec
+	defer func() { c.radix = radix }()
+
+	return c.Batch(func() error {
+		history := slices.Clone(c.history)
+		if err := c.RunTokens(tokens); err != nil {
+			return err
+		}
+		c.history = history
+		c.AddHistory(fmt.Sprintf("%s = %s", expr, c.PeekValue()))
+		return nil
+	})
+}
+
+// an entry on the shunting-yard operator stack
+type infixEntry struct {
+	op     infixOperator
+	paren  bool
+	fn     *Command // function call, sits under its paren
+	commas int
+}
+
+// turn an infix expression into RPN tokens for RunTokens
+func (c *Calculator) compileInfix(expr string) ([]string, error) {
+	if expr == "" {
+		return nil, errors.New("missing expression")
+	}
+
+	var out []string
+	var stack []infixEntry
+	depth := 0 // how many values the RPN will have pushed
+	emit := func(pops int, tokens ...string) error {
+		if depth < pops {
+			return errors.New("missing operand")
+		}
+		depth += 1 - pops
+		out = append(out, tokens...)
+		return nil
+	}
+	// pop operators while they bind tighter than op
+	unwind := func(op *infixOperator) error {
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if top.paren || (op != nil && (top.op.precedence < op.precedence || (top.op.precedence == op.precedence && op.right))) {
+				break
+			}
+			stack = stack[:len(stack)-1]
+			if err := emit(lo.Ternary(top.op.name == NEG, 1, 2), top.op.name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// afterValue is true when the last token was a number, name or ")"
+	afterValue := false
+	for s := expr; strings.TrimSpace(s) != ""; {
+		m := infixTokenRe.FindStringSubmatch(s)
+		if m == nil {
+			return nil, fmt.Errorf("unexpected %q", strings.TrimSpace(s)[:1])
+		}
+		s = s[len(m[0]):]
+		number, name, punct := m[1], m[2], m[3]
+
+		if (number != "" || name != "" || punct == "(") && afterValue {
+			return nil, errors.New("missing operator")
+		}
+		switch {
+		case number != "":
+			_ = emit(0, number)
+			afterValue = true
+
+		case name != "" && strings.HasPrefix(strings.TrimSpace(s), "("):
+			cmd, err := infixFunction(name)
+			if err != nil {
+				return nil, err
+			}
+			s = strings.TrimSpace(s)[1:]
+			stack = append(stack, infixEntry{paren: true, fn: &cmd})
+
+		case name != "":
+			tokens, err := c.infixName(name)
+			if err != nil {
+				return nil, err
+			}
+			_ = emit(0, tokens...)
+			afterValue = true
+
+		case punct == "(":
+			stack = append(stack, infixEntry{paren: true})
+
+		case punct == ",":
+			if err := unwind(nil); err != nil {
+				return nil, err
+			}
+			if len(stack) == 0 || stack[len(stack)-1].fn == nil || !afterValue {
+				return nil, errors.New("unexpected ,")
+			}
+			stack[len(stack)-1].commas++
+			afterValue = false
+
+		case punct == ")":
+			if err := unwind(nil); err != nil {
+				return nil, err
+			}
+			if len(stack) == 0 {
+				return nil, errors.New("mismatched parens")
+			}
+			paren := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if paren.fn == nil {
+				if !afterValue {
+					return nil, errors.New("missing operand")
+				}
+				continue
+			}
+			args := lo.Ternary(afterValue, paren.commas+1, 0)
+			if n, _ := arity(*paren.fn); args != n {
+				return nil, fmt.Errorf("%s takes %d %s", strings.ToLower(paren.fn.Name), n, lo.Ternary(n == 1, "argument", "arguments"))
+			}
+			if err := emit(args, paren.fn.Name); err != nil {
+				return nil, err
+			}
+			afterValue = true
+
+		case !afterValue:
+			// unary
+			if punct == "-" {
+				stack = append(stack, infixEntry{op: infixNeg})
+			} else if punct != "+" {
+				return nil, errors.New("missing operand")
+			}
+
+		default:
+			op := infixOperators[punct]
+			if err := unwind(&op); err != nil {
+				return nil, err
+			}
+			stack = append(stack, infixEntry{op: op})
+			afterValue = false
+		}
+	}
+
+	if err := unwind(nil); err != nil {
+		return nil, err
+	}
+	switch {
+	case len(stack) > 0:
+		return nil, errors.New("mismatched parens")
+	case depth != 1:
+		return nil, errors.New("missing operand")
+	}
+	return out, nil
+}
+
+// a command that can be called like sqrt(x), taking numbers and pushing one
+// result
+func infixFunction(name string) (Command, error) {
+	cmd, ok := CommandsByName[strings.ToUpper(name)]
+	if !ok {
+		return Command{}, fmt.Errorf("unknown function %s", name)
+	}
+	switch cmd.fn.(type) {
+	case func(*Calculator) Num, func(*Calculator) Value,
+		func(*Calculator, Num) Num, func(*Calculator, Number) Number, func(*Calculator, Value) Value,
+		func(*Calculator, Num, Num) Num, func(*Calculator, Num, Num) Value, func(*Calculator, Number, Number) Number,
+		func(*Calculator, Value, Value) Value, func(*Calculator, Num, Num, Num) Value:
+		if cmd.Arg == "" && !cmd.counted {
+			return cmd, nil
+		}
+	}
+	return Command{}, fmt.Errorf("%s can't be used in an expression", name)
+}
+
+// a bare name, like pi or a register
+func (c *Calculator) infixName(name string) ([]string, error) {
+	if cmd, err := infixFunction(name); err == nil {
+		if n, _ := arity(cmd); n == 0 {
+			return []string{cmd.Name}, nil
+		}
+	}
+	if _, ok := c.registers[name]; ok {
+		return []string{"RCL", name}, nil
+	}
+	return nil, fmt.Errorf("unknown name %s", name)
+}
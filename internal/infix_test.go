@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileInfix(t *testing.T) {
+	c := NewCalculator()
+	c.storeRegister("x", decimal.NewFromInt(3))
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"1 + 2", "1 2 ADD"},
+		{"1 + 2 * 3", "1 2 3 MUL ADD"},
+		{"(1 + 2) * 3", "1 2 ADD 3 MUL"},
+		{"2 ^ 3 ^ 2", "2 3 2 POW POW"},
+		{"8 - 2 - 1", "8 2 SUB 1 SUB"},
+		{"-2^2", "2 2 POW NEG"},
+		{"2 * -3", "2 3 NEG MUL"},
+		{"+1.5e3", "1.5e3"},
+		{"(1 + sqrt(5)) / 2", "1 5 SQRT ADD 2 DIV"},
+		{"pow(2, 10)", "2 10 POW"},
+		{"2 * pi", "2 PI MUL"},
+		{"x * 0xff", "RCL x 0xff MUL"},
+	}
+	for _, tc := range tests {
+		tokens, err := c.compileInfix(tc.expr)
+		assert.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.expected, strings.Join(tokens, " "), tc.expr)
+	}
+
+	errors := []struct {
+		expr     string
+		expected string
+	}{
+		{"", "missing expression"},
+		{"1 +", "missing operand"},
+		{"* 2", "missing operand"},
+		{"()", "missing operand"},
+		{"1 2", "missing operator"},
+		{"(1 + 2", "mismatched parens"},
+		{"1 + 2)", "mismatched parens"},
+		{"sqrt(1, 2)", "sqrt takes 1 argument"},
+		{"pow(2)", "pow takes 2 arguments"},
+		{"1 , 2", "unexpected ,"},
+		{"1 # 2", `unexpected "#"`},
+		{"bogus(1)", "unknown function bogus"},
+		{"dup(1)", "dup can't be used in an expression"},
+		{"y + 1", "unknown name y"},
+	}
+	for _, tc := range errors {
+		_, err := c.compileInfix(tc.expr)
+		assert.EqualError(t, err, tc.expected, tc.expr)
+	}
+}
+
+func TestRunInfix(t *testing.T) {
+	c := NewCalculator()
+	c.PushInt(7)
+	assert.NoError(t, c.RunInfix(" (1 + sqrt(5)) / 2 "))
+	assert.Equal(t, []string{"7", "1.6180339888"}, c.GetStackString())
+	assert.Equal(t, []string{"(1 + sqrt(5)) / 2 = 1.6180339888"}, c.GetHistory())
+
+	// one undo step
+	assert.NoError(t, c.Run(UNDO))
+	assert.Equal(t, []string{"7"}, c.GetStackString())
+
+	// errors leave the stack alone
+	assert.EqualError(t, c.RunInfix("1 / 0"), "DIV: divide by zero")
+	assert.Equal(t, []string{"7"}, c.GetStackString())
+
+	// numbers are decimal in HEX too, and prefixes still work
+	c.Clear()
+	c.SetRadix(Hex)
+	assert.NoError(t, c.RunInfix("10 + 1"))
+	assert.NoError(t, c.RunInfix("0xff + 0b101"))
+	assert.Equal(t, []string{"11", "260"}, c.GetStackString())
+	assert.EqualError(t, c.RunInfix("ff + 1"), "unknown name ff")
+	assert.Equal(t, Hex, c.GetRadix())
+}
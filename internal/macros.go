@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+)
+
+//
+// User-defined macros, like ": hyp dup * swap dup * + sqrt ;". A macro runs
+// its tokens through RunTokens as a single undo step, and can be bound to a
+// key. Values in the program are always read in the radix the macro was
+// defined in, so "ff" means 255 even when the macro runs in DEC.
+//
+
+type Macro struct {
+	Name   string
+	Key    string
+	Tokens []string
+	Radix  Radix
+}
+
+// the program, like "dup * swap dup * + sqrt"
+func (m Macro) Program() string {
+	return strings.Join(m.Tokens, " ")
+}
+
+func (c *Calculator) GetMacros() []Macro {
+	return slices.SortedFunc(maps.Values(c.macros), func(a, b Macro) int { return strings.Compare(a.Name, b.Name) })
+}
+
+func (c *Calculator) SetMacros(macros []Macro) {
+	c.macros = map[string]Macro{}
+	for _, macro := range macros {
+		c.macros[macro.Name] = macro
+	}
+}
+
+// find the macro bound to a key, if any
+func (c *Calculator) MacroByKey(key string) (Macro, bool) {
+	for _, macro := range c.macros {
+		if macro.Key == key {
+			return macro, true
+		}
+	}
+	return Macro{}, false
+}
+
+// Run a macro by name. If anything fails the whole macro is rolled back.
+func (c *Calculator) RunMacro(name string) error {
+	macro, ok := c.macros[name]
+	if !ok {
+		return errors.New("no such macro")
+	}
+	if c.depth >= MaxMacroDepth {
+		return errors.New("macros nested too deeply")
+	}
+	c.depth++
+	defer func() { c.depth-- }()
+	parse := func(s string) (Value, error) { return c.ParseRadix(s, macro.Radix) }
+	return c.Batch(func() error { return c.runTokens(macro.Tokens, parse) })
+}
+
+// parse "hyp dup * swap dup * + sqrt", with optional ":" and ";"
+func (c *Calculator) parseMacro(s string) (Macro, error) {
+	tokens := strings.Fields(s)
+	if len(tokens) > 0 && tokens[0] == ":" {
+		tokens = tokens[1:]
+	}
+	if len(tokens) > 0 && tokens[len(tokens)-1] == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) < 2 {
+		return Macro{}, errors.New("missing program")
+	}
+
+	name, tokens := Shift(tokens)
+	if !registerNameRe.MatchString(name) {
+		return Macro{}, errors.New("bad macro name")
+	}
+	if _, ok := LookupCommand(name); ok {
+		return Macro{}, errors.New("macro name is a command")
+	}
+	for ii := 0; ii < len(tokens); ii++ {
+		token := tokens[ii]
+		if cmd, ok := LookupCommand(token); ok {
+			if cmd.Arg != "" {
+				ii++ // skip the argument
+			}
+			continue
+		}
+		if _, ok := c.macros[token]; ok || token == name {
+			continue
+		}
+		if _, err := c.Parse(token); err != nil {
+			return Macro{}, errors.New(token + ": unknown command")
+		}
+	}
+
+	macro := Macro{Name: name, Tokens: tokens, Radix: c.radix}
+	if old, ok := c.macros[name]; ok {
+		macro.Key = old.Key
+	}
+	return macro, nil
+}
+
+// parse "hyp H"
+func (c *Calculator) parseBind(s string) (Macro, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return Macro{}, errors.New("expected macro and key")
+	}
+	macro, ok := c.macros[fields[0]]
+	if !ok {
+		return Macro{}, errors.New("no such macro")
+	}
+	key := fields[1]
+	if c.isBoundKey(key) || IsPartialValue(key, c.radix) {
+		return Macro{}, errors.New("key is already used")
+	}
+	if other, ok := c.MacroByKey(key); ok && other.Name != macro.Name {
+		return Macro{}, errors.New("key is already used")
+	}
+	macro.Key = key
+	return macro, nil
+}
+
+// is key bound to a command (or action) in the keymap?
+func (c *Calculator) isBoundKey(key string) bool {
+	if c.boundKeys == nil {
+		_, ok := CommandsByKey[key]
+		return ok
+	}
+	return c.boundKeys[key]
+}
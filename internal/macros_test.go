@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMacros(t *testing.T) {
+	c := NewCalculator()
+	assert.NoError(t, c.RunTokens([]string{":", "hyp", "dup", "*", "swap", "dup", "*", "+", "sqrt", ";"}))
+	assert.Equal(t, "dup * swap dup * + sqrt", c.GetMacros()[0].Program())
+
+	// run by name, as one undo step
+	assert.NoError(t, c.RunTokens([]string{"3", "4", "hyp"}))
+	assert.Equal(t, []string{"5"}, c.GetStackString())
+	c.Clear()
+	c.PushInt(3, 4)
+	assert.NoError(t, c.Run("hyp"))
+	assert.NoError(t, c.Run(UNDO))
+	assert.Equal(t, []string{"3", "4"}, c.GetStackString())
+
+	// failure rolls back
+	c.Clear()
+	c.PushInt(3)
+	assert.EqualError(t, c.Run("hyp"), "SWAP: too few arguments")
+	assert.Equal(t, []string{"3"}, c.GetStackString())
+	assert.Empty(t, c.History())
+
+	// macros calling macros, and runaway recursion
+	assert.NoError(t, c.RunArg(DEF, "twice hyp hyp"))
+	assert.NoError(t, c.RunArg(DEF, "forever 1 forever"))
+	assert.EqualError(t, c.Run("forever"), "macros nested too deeply")
+	assert.Equal(t, []string{"3"}, c.GetStackString())
+
+	// bind/undef
+	assert.NoError(t, c.RunArg("BIND", "hyp H"))
+	macro, ok := c.MacroByKey("H")
+	assert.True(t, ok)
+	assert.Equal(t, "hyp", macro.Name)
+	assert.NoError(t, c.RunArg(DEF, "hyp dup *"))
+	assert.Equal(t, "H", c.GetMacros()[1].Key)
+	assert.NoError(t, c.RunArg("UNDEF", "hyp"))
+	_, ok = c.MacroByKey("H")
+	assert.False(t, ok)
+}
+
+func TestMacrosRollback(t *testing.T) {
+	// modes roll back too
+	c := NewCalculator()
+	c.PushInt(1)
+	prec := c.GetPrecision()
+	assert.NoError(t, c.RunArg(DEF, "bad hex 10 prec 0 /"))
+	assert.Error(t, c.Run("bad"))
+	assert.Equal(t, Dec, c.GetRadix())
+	assert.Equal(t, prec, c.GetPrecision())
+	assert.Equal(t, []string{"1"}, c.GetStackString())
+}
+
+func TestMacrosRadix(t *testing.T) {
+	// values are read in the radix the macro was defined in
+	c := NewCalculator()
+	c.SetRadix(Hex)
+	assert.NoError(t, c.RunArg(DEF, "big ff"))
+	c.SetRadix(Dec)
+	assert.NoError(t, c.Run("big"))
+	assert.Equal(t, []string{"255"}, c.GetStackString())
+	assert.NoError(t, c.RunArg(DEF, "ten 10"))
+	c.SetRadix(Hex)
+	assert.NoError(t, c.Run("ten"))
+	assert.Equal(t, Dec, c.GetMacros()[1].Radix)
+	assert.Equal(t, []string{"255", "10"}, c.GetStackString())
+}
+
+func TestMacrosValid(t *testing.T) {
+	c := NewCalculator()
+	assert.EqualError(t, c.RunArg(DEF, "hyp"), "missing program")
+	assert.EqualError(t, c.RunArg(DEF, "1x dup"), "bad macro name")
+	assert.EqualError(t, c.RunArg(DEF, "dup dup"), "macro name is a command")
+	assert.EqualError(t, c.RunArg(DEF, "hyp dup bogus"), "bogus: unknown command")
+	assert.NoError(t, c.RunArg(DEF, "blue tailwind blue-400"))
+	assert.EqualError(t, c.RunTokens([]string{":", "hyp", "dup"}), ": missing ;")
+
+	assert.EqualError(t, c.RunArg("BIND", "blue"), "expected macro and key")
+	assert.EqualError(t, c.RunArg("BIND", "hyp H"), "no such macro")
+	assert.EqualError(t, c.RunArg("BIND", "blue +"), "key is already used")
+	assert.EqualError(t, c.RunArg("BIND", "blue 1"), "key is already used")
+	assert.EqualError(t, c.RunArg("UNDEF", "hyp"), "no such macro")
+}
@@ -0,0 +1,117 @@
+package internal
+
+import "slices"
+
+//
+// calculator modes, which appear in the status bar and are saved with the state
+//
+
+// angle mode for trig
+type AngleMode int
+
+const (
+	Deg AngleMode = iota
+	Rad
+	Grad
+)
+
+var angleModeNames = []string{"DEG", "RAD", "GRAD"}
+
+func (m AngleMode) String() string {
+	return angleModeNames[m]
+}
+
+// parse "DEG", "RAD" or "GRAD"
+func ParseAngleMode(s string) (AngleMode, bool) {
+	ii := slices.Index(angleModeNames, s)
+	return AngleMode(max(ii, 0)), ii != -1
+}
+
+// radix for display and input of integers
+type Radix int
+
+const (
+	Dec Radix = iota
+	Hex
+	Bin
+	Oct
+)
+
+var (
+	radixNames    = []string{"DEC", "HEX", "BIN", "OCT"}
+	radixBases    = []int{10, 16, 2, 8}
+	radixPrefixes = []string{"", "0x", "0b", "0o"}
+)
+
+func (r Radix) String() string {
+	return radixNames[r]
+}
+
+func (r Radix) Base() int {
+	return radixBases[r]
+}
+
+// parse "DEC", "HEX", etc.
+func ParseRadix(s string) (Radix, bool) {
+	ii := slices.Index(radixNames, s)
+	return Radix(max(ii, 0)), ii != -1
+}
+
+// word sizes for bitwise operations
+var WordSizes = []int{8, 16, 32, 64}
+
+// rounding mode for ROUND and ROUNDN
+type RoundingMode int
+
+const (
+	HalfUp RoundingMode = iota
+	HalfEven
+	TowardZero
+)
+
+var roundingModeNames = []string{"HALF-UP", "HALF-EVEN", "TO-ZERO"}
+
+func (m RoundingMode) String() string {
+	return roundingModeNames[m]
+}
+
+// parse "HALF-UP", "HALF-EVEN" or "TO-ZERO"
+func ParseRoundingMode(s string) (RoundingMode, bool) {
+	ii := slices.Index(roundingModeNames, s)
+	return RoundingMode(max(ii, 0)), ii != -1
+}
+
+// round x to places using this mode
+func (m RoundingMode) Round(x Num, places int32) Num {
+	switch m {
+	case HalfEven:
+		return x.RoundBank(places)
+	case TowardZero:
+		return x.RoundDown(places)
+	case HalfUp:
+	}
+	return x.Round(places)
+}
+
+// display mode for Nums, see formatDisplay
+type DisplayMode int
+
+const (
+	Std DisplayMode = iota
+	Fix
+	Sci
+	Eng
+	SI
+)
+
+var displayModeNames = []string{"STD", "FIX", "SCI", "ENG", "SI"}
+
+func (m DisplayMode) String() string {
+	return displayModeNames[m]
+}
+
+// parse "STD", "FIX", etc.
+func ParseDisplayMode(s string) (DisplayMode, bool) {
+	ii := slices.Index(displayModeNames, s)
+	return DisplayMode(max(ii, 0)), ii != -1
+}
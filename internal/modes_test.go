@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseModes(t *testing.T) {
+	angle, ok := ParseAngleMode("GRAD")
+	assert.True(t, ok)
+	assert.Equal(t, Grad, angle)
+	_, ok = ParseAngleMode("bogus")
+	assert.False(t, ok)
+
+	radix, ok := ParseRadix("HEX")
+	assert.True(t, ok)
+	assert.Equal(t, 16, radix.Base())
+	_, ok = ParseRadix("")
+	assert.False(t, ok)
+
+	rounding, ok := ParseRoundingMode("TO-ZERO")
+	assert.True(t, ok)
+	assert.Equal(t, TowardZero, rounding)
+}
+
+func TestRoundingMode(t *testing.T) {
+	tests := []struct {
+		input    float64
+		places   int32
+		expected []string // HalfUp, HalfEven, TowardZero
+	}{
+		{2.5, 0, []string{"3", "2", "2"}},
+		{-2.5, 0, []string{"-3", "-2", "-2"}},
+		{1.235, 2, []string{"1.24", "1.24", "1.23"}},
+		{1.245, 2, []string{"1.25", "1.24", "1.24"}},
+		{1250, -2, []string{"1300", "1200", "1200"}},
+	}
+	for _, tc := range tests {
+		for mode, expected := range tc.expected {
+			x := RoundingMode(mode).Round(decimal.NewFromFloat(tc.input), tc.places)
+			assert.Equal(t, expected, x.String(), "%v %s", tc.input, RoundingMode(mode))
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+//
+// parsing user input
+//
+
+var (
+	prefixedIntRe = regexp.MustCompile(`^[+-]?0[xXbBoO]`)
+	// b is a hex digit, so in HEX 0b101 is just a number
+	prefixedHexRe = regexp.MustCompile(`^[+-]?0[xX]`)
+	partialNumRes = map[Radix]*regexp.Regexp{
+		Dec: regexp.MustCompile(`^[+-]?(\d*|0[xX][\da-fA-F]*|0[bB][01]*|0[oO][0-7]*)$`),
+		Hex: regexp.MustCompile(`^[+-]?(0[xX])?[\da-fA-F]*$`),
+		Bin: regexp.MustCompile(`^[+-]?(0[bB])?[01]*$`),
+		Oct: regexp.MustCompile(`^[+-]?(0[oO])?[0-7]*$`),
+	}
+	currencyRe = regexp.MustCompile(`\p{Sc}`)
+)
+
+// parse a number. Understands 0x/0b/0o prefixes (only 0x in HEX), and
+// integers without a prefix are read in radix
+func ParseNum(s string, radix Radix) (Num, error) {
+	if lo.Ternary(radix == Hex, prefixedHexRe, prefixedIntRe).MatchString(s) {
+		return parseInt(s, 0)
+	}
+	if radix != Dec {
+		return parseInt(s, radix.Base())
+	}
+	return decimal.NewFromString(s)
+}
+
+// is this the start of an integer in radix? Used to decide if a letter belongs
+// in the text input, like the "x" in "0x".
+func IsPartialNum(s string, radix Radix) bool {
+	return partialNumRes[radix].MatchString(s)
+}
+
+// split pasted text into tokens for RunTokens, cleaning up numbers like
+// "$1,234.50" along the way. Separators come from the locale, see SetLocale
+func (c *Calculator) ParsePaste(s string) []string {
+	t, p := regexp.QuoteMeta(c.thousands), regexp.QuoteMeta(c.point)
+	localeRe := regexp.MustCompile(`^[+-]?(\d{1,3}(` + t + `\d{3})+|\d+)(` + p + `\d*)?([eE][+-]?\d+)?$`)
+	return MapV(strings.Fields(s), func(s string) string { return c.cleanNumber(s, localeRe) })
+}
+
+// "$1,234.50" => "1234.50", and "(12.00)" is negative like a spreadsheet.
+// Numbers written for the locale (localeRe) lose their separators. Anything
+// that doesn't look like a number is left alone
+func (c *Calculator) cleanNumber(s string, localeRe *regexp.Regexp) string {
+	x := currencyRe.ReplaceAllString(s, "")
+	if len(x) > 2 && strings.HasPrefix(x, "(") && strings.HasSuffix(x, ")") {
+		x = "-" + x[1:len(x)-1]
+	}
+	if localeRe.MatchString(x) {
+		x = strings.Replace(strings.ReplaceAll(x, c.thousands, ""), c.point, ".", 1)
+	}
+	if _, err := decimal.NewFromString(x); err != nil {
+		return s
+	}
+	return x
+}
+
+func parseInt(s string, base int) (Num, error) {
+	x, ok := new(big.Int).SetString(s, base)
+	if !ok {
+		return Num{}, errors.New("invalid number")
+	}
+	return decimal.NewFromBigInt(x, 0), nil
+}
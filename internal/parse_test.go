@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNum(t *testing.T) {
+	tests := []struct {
+		input    string
+		radix    Radix
+		expected string
+	}{
+		{"1.5", Dec, "1.5"},
+		{"-2e3", Dec, "-2000"},
+		{"0x1F", Dec, "31"},
+		{"-0x10", Dec, "-16"},
+		{"0b101", Dec, "5"},
+		{"0o17", Dec, "15"},
+		{"017", Dec, "17"},
+		{"ff", Hex, "255"},
+		{"0b101", Hex, "45313"},
+		{"0x10", Hex, "16"},
+		{"101", Bin, "5"},
+		{"0x10", Bin, "16"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			x, err := ParseNum(tc.input, tc.radix)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, x.String())
+		})
+	}
+
+	for _, s := range []string{"", "abc", "0x", "1.5.5", "0b102"} {
+		_, err := ParseNum(s, Dec)
+		assert.Error(t, err, s)
+	}
+	_, err := ParseNum("1.5", Hex)
+	assert.Error(t, err)
+}
+
+func TestIsPartialNum(t *testing.T) {
+	assert.True(t, IsPartialNum("0x", Dec))
+	assert.True(t, IsPartialNum("0xa", Dec))
+	assert.True(t, IsPartialNum("-0b", Dec))
+	assert.False(t, IsPartialNum("1x", Dec))
+	assert.False(t, IsPartialNum("0xg", Dec))
+	assert.False(t, IsPartialNum("1a", Dec))
+	assert.True(t, IsPartialNum("1a", Hex))
+	assert.False(t, IsPartialNum("12", Bin))
+}
+
+func TestParsePaste(t *testing.T) {
+	c := NewCalculator()
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"1,234.50\n2,000\n", []string{"1234.50", "2000"}},
+		{"$1,234.50 €99 -$5 (12.00)", []string{"1234.50", "99", "-5", "-12.00"}},
+		{"6.02e23\t1E-3", []string{"6.02e23", "1E-3"}},
+		{"3 4 +", []string{"3", "4", "+"}},
+		{"1,2 0xff 5_m", []string{"1,2", "0xff", "5_m"}},
+		{"  \n", []string{}},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, c.ParsePaste(tc.input), tc.input)
+	}
+
+	// locale separators
+	c.SetLocale("de_DE.UTF-8")
+	assert.Equal(t, []string{"1234.50", "12.5", "1500", "1.5", "-3"}, c.ParsePaste("1.234,50 12,5 1.500 1.5 (3€)"))
+	c.SetLocale("de_CH.UTF-8")
+	assert.Equal(t, []string{"1234.50"}, c.ParsePaste("1'234.50"))
+}
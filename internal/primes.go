@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+
+	"github.com/shopspring/decimal"
+)
+
+//
+// number theory
+//
+
+// Factor gives up after this many rounds of pollard's rho, so huge numbers
+// don't lock up the ui
+var FactorBudget = 50_000
+
+var errTooHard = errors.New("too hard to factor")
+
+// greatest common divisor, always >= 0
+func Gcd(a, b *big.Int) *big.Int {
+	return new(big.Int).GCD(nil, nil, new(big.Int).Abs(a), new(big.Int).Abs(b))
+}
+
+// least common multiple, always >= 0
+func Lcm(a, b *big.Int) *big.Int {
+	gcd := Gcd(a, b)
+	if gcd.Sign() == 0 {
+		return gcd
+	}
+	lcm := new(big.Int).Mul(a, b)
+	return lcm.Abs(lcm).Quo(lcm, gcd)
+}
+
+// is x prime? This is probabilistic for very large x, but very reliable
+func IsPrime(x *big.Int) bool {
+	return x.ProbablyPrime(20)
+}
+
+// Factor as Nums, saved for FACTOR after validFactor
+func (c *Calculator) factor(a Num) ([]Num, error) {
+	return c.factors.get(a.String(), func() ([]Num, error) {
+		factors, err := Factor(a.BigInt())
+		return MapV(factors, func(x *big.Int) Num { return decimal.NewFromBigInt(x, 0) }), err
+	})
+}
+
+// prime factors of x > 1, smallest first
+func Factor(x *big.Int) ([]*big.Int, error) {
+	var factors []*big.Int
+	n := new(big.Int).Set(x)
+
+	// trial division for the small stuff
+	for p := int64(2); p < 1000; p++ {
+		bp := big.NewInt(p)
+		for new(big.Int).Mod(n, bp).Sign() == 0 {
+			factors = append(factors, bp)
+			n.Quo(n, bp)
+		}
+	}
+
+	// pollard's rho for the rest
+	budget := FactorBudget
+	var err error
+	if factors, err = rho(n, factors, &budget); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(factors, (*big.Int).Cmp)
+	return factors, nil
+}
+
+// recursively split n with pollard's rho, append primes to factors
+func rho(n *big.Int, factors []*big.Int, budget *int) ([]*big.Int, error) {
+	one := big.NewInt(1)
+	if n.Cmp(one) == 0 {
+		return factors, nil
+	}
+	if IsPrime(n) {
+		return append(factors, n), nil
+	}
+
+	for c := int64(1); ; c++ {
+		cc := big.NewInt(c)
+		x, y, d, diff := big.NewInt(2), big.NewInt(2), big.NewInt(1), new(big.Int)
+		f := func(v *big.Int) {
+			v.Mul(v, v).Add(v, cc).Mod(v, n)
+		}
+		for d.Cmp(one) == 0 {
+			if *budget--; *budget < 0 {
+				return nil, errTooHard
+			}
+			f(x)
+			f(y)
+			f(y)
+			d.GCD(nil, nil, diff.Abs(diff.Sub(x, y)), n)
+		}
+		if d.Cmp(n) != 0 {
+			var err error
+			if factors, err = rho(d, factors, budget); err != nil {
+				return nil, err
+			}
+			return rho(new(big.Int).Quo(n, d), factors, budget)
+		}
+	}
+}
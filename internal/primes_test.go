@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGcdLcm(t *testing.T) {
+	assert.Equal(t, int64(6), Gcd(big.NewInt(12), big.NewInt(-18)).Int64())
+	assert.Equal(t, int64(0), Gcd(big.NewInt(0), big.NewInt(0)).Int64())
+	assert.Equal(t, int64(36), Lcm(big.NewInt(-12), big.NewInt(18)).Int64())
+	assert.Equal(t, int64(0), Lcm(big.NewInt(0), big.NewInt(0)).Int64())
+}
+
+func TestIsPrime(t *testing.T) {
+	for _, x := range []int64{2, 3, 97, 1_000_000_007} {
+		assert.True(t, IsPrime(big.NewInt(x)), x)
+	}
+	for _, x := range []int64{-7, 0, 1, 4, 91, 1_000_000_008} {
+		assert.False(t, IsPrime(big.NewInt(x)), x)
+	}
+}
+
+func TestFactor(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"2", []string{"2"}},
+		{"360", []string{"2", "2", "2", "3", "3", "5"}},
+		{"1000000007", []string{"1000000007"}},
+		// two big primes
+		{"1000000014000000049", []string{"1000000007", "1000000007"}},
+		{"1000000016000000063", []string{"1000000007", "1000000009"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			x, _ := new(big.Int).SetString(tc.input, 10)
+			factors, err := Factor(x)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, MapV(factors, (*big.Int).String))
+		})
+	}
+
+	// product of two ~40 digit primes is too hard
+	p, _ := new(big.Int).SetString("1000000000000000000000000000000000000007", 10)
+	q, _ := new(big.Int).SetString("1000000000000000000000000000000000000121", 10)
+	_, err := Factor(new(big.Int).Mul(p, q))
+	assert.ErrorIs(t, err, errTooHard)
+}
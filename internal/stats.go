@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/shopspring/decimal"
+)
+
+//
+// Statistics over a list of values, either the whole stack or the top n (see
+// the N commands, like SUMN). Linear regression accumulates x, y points into
+// the Σ registers with Σ+, and LINREG turns them into slope, intercept and r.
+//
+
+func Sum(values []Num) Num {
+	var sum Num
+	for _, x := range values {
+		sum = sum.Add(x)
+	}
+	return sum
+}
+
+func Product(values []Num) Num {
+	p := One
+	for _, x := range values {
+		p = p.Mul(x)
+	}
+	return p
+}
+
+func Mean(values []Num, prec int) Num {
+	return Div(Sum(values), decimal.NewFromInt(int64(len(values))), prec)
+}
+
+func Median(values []Num, prec int) Num {
+	return Percentile(values, decimal.NewFromInt(50), prec)
+}
+
+// sample variance (divide by n - 1), or population variance (divide by n)
+func Variance(values []Num, sample bool, prec int) Num {
+	mean := Mean(values, prec)
+	var sum Num
+	for _, x := range values {
+		sum = sum.Add(x.Sub(mean).Pow(Two))
+	}
+	n := len(values)
+	if sample {
+		n--
+	}
+	return Div(sum, decimal.NewFromInt(int64(n)), prec)
+}
+
+// the pth percentile, interpolating between the closest ranks like a
+// spreadsheet PERCENTILE
+func Percentile(values []Num, p Num, prec int) Num {
+	sorted := slices.SortedFunc(slices.Values(values), Num.Cmp)
+	rank := Div(p.Mul(decimal.NewFromInt(int64(len(sorted)-1))), decimal.NewFromInt(100), prec)
+	ii := int(rank.IntPart())
+	if ii >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := rank.Sub(decimal.NewFromInt(int64(ii)))
+	return sorted[ii].Add(sorted[ii+1].Sub(sorted[ii]).Mul(frac))
+}
+
+//
+// linear regression
+//
+
+// the Σ sums, in order. These aren't STO/RCL registers
+var sigmaNames = []string{"Σn", "Σx", "Σy", "Σxx", "Σyy", "Σxy"}
+
+// add (or remove, if sign is -1) a point
+func (c *Calculator) accumulate(x, y Num, sign int64) {
+	if c.sigmaValues == nil {
+		c.sigmaValues = map[string]Num{}
+	}
+	s := decimal.NewFromInt(sign)
+	for ii, delta := range []Num{One, x, y, x.Mul(x), y.Mul(y), x.Mul(y)} {
+		name := sigmaNames[ii]
+		c.sigmaValues[name] = c.sigmaValues[name].Add(delta.Mul(s))
+	}
+}
+
+// slope, intercept and correlation coefficient r for the accumulated points
+func (c *Calculator) linreg() (slope, intercept, r Num, err error) {
+	sums := MapV(sigmaNames, func(name string) Num { return c.sigmaValues[name] })
+	n, sx, sy, sxx, syy, sxy := sums[0], sums[1], sums[2], sums[3], sums[4], sums[5]
+	if n.LessThan(Two) {
+		return slope, intercept, r, errors.New("need at least two points")
+	}
+	xx := sxx.Sub(Div(sx.Mul(sx), n, c.precision))
+	yy := syy.Sub(Div(sy.Mul(sy), n, c.precision))
+	xy := sxy.Sub(Div(sx.Mul(sy), n, c.precision))
+	if !xx.IsPositive() {
+		return slope, intercept, r, errors.New("no variation in x")
+	}
+	slope = Div(xy, xx, c.precision)
+	intercept = Div(sy.Sub(slope.Mul(sx)), n, c.precision)
+	// a flat line has no correlation to speak of, so r stays 0
+	if yy.IsPositive() {
+		r = Div(xy, Pow(xx.Mul(yy), Half, c.precision), c.precision)
+	}
+	return slope, intercept, r, nil
+}
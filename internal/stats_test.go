@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	values := MapV([]int64{15, 20, 35, 40, 50}, decimal.NewFromInt)
+	tests := []struct {
+		p        int64
+		expected string
+	}{
+		{0, "15"},
+		{25, "20"},
+		{40, "29"},
+		{50, "35"},
+		{90, "46"},
+		{100, "50"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, Percentile(values, decimal.NewFromInt(tc.p), Precision).String(), tc.p)
+	}
+}
+
+func TestStats(t *testing.T) {
+	c := NewCalculator()
+
+	// one history line, not partial sums
+	assert.NoError(t, c.RunTokens([]string{"10", "20", "30", "sum"}))
+	assert.Equal(t, []string{"60"}, c.GetStackString())
+	assert.Equal(t, []string{"sum(10, 20, 30) = 60"}, c.GetHistory())
+
+	// top n
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"100", "1", "2", "3", "3", "meann"}))
+	assert.Equal(t, []string{"100", "2"}, c.GetStackString())
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"15", "20", "35", "40", "50", "percentile", "40"}))
+	assert.Equal(t, []string{"29"}, c.GetStackString())
+
+	// fracs are fine
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"1/2", "1/4", "max"}))
+	assert.Equal(t, []string{"0.5"}, c.GetStackString())
+
+	// errors
+	c.Clear()
+	assert.EqualError(t, c.Run("SUM"), "stack is empty")
+	c.PushInt(1)
+	assert.EqualError(t, c.Run("VAR"), "too few arguments")
+	assert.NoError(t, c.Run("PVAR"))
+	assert.EqualError(t, c.RunArg("PERCENTILE", "101"), "percentile must be 0 to 100")
+	c.PushInt(0)
+	assert.EqualError(t, c.Run("SUMN"), "too few arguments")
+	c.Clear()
+	c.PushValue(NewColorRGB(1, 2, 3))
+	c.PushInt(1)
+	assert.EqualError(t, c.Run("SUM"), "not a number")
+	assert.EqualError(t, c.Run("SUMN"), "not a number")
+}
+
+func TestLinreg(t *testing.T) {
+	c := NewCalculator()
+	assert.EqualError(t, c.Run("LINREG"), "need at least two points")
+
+	// y = 2x + 1, plus a typo that gets removed
+	assert.NoError(t, c.RunTokens([]string{"1", "3", "Σ+", "2", "5", "Σ+", "9", "9", "Σ+", "9", "9", "Σ-", "3", "7", "Σ+"}))
+	assert.Equal(t, "3", c.sigmaValues["Σn"].String())
+	assert.Empty(t, c.GetRegisters())
+	sums := c.GetSigmaString()
+	assert.Equal(t, "6", sums["Σx"])
+	c.SetSigmaString(map[string]string{"Σn": "bogus"})
+	assert.Empty(t, c.sigmaValues)
+	c.SetSigmaString(sums)
+	assert.NoError(t, c.Run("LINREG"))
+	assert.Equal(t, []string{"2", "1", "1"}, c.GetStackString())
+	assert.Contains(t, c.GetHistory(), "Σ+ 1, 3")
+
+	// not a perfect fit
+	c.Clear()
+	assert.NoError(t, c.Run("CLΣ"))
+	assert.NoError(t, c.RunTokens([]string{"1", "1", "Σ+", "2", "3", "Σ+", "3", "2", "Σ+", "linreg"}))
+	assert.Equal(t, []string{"0.5", "1", "0.5"}, c.GetStackString())
+
+	// same y is a flat line, with ascii names
+	c.Clear()
+	assert.NoError(t, c.Run("CLS"))
+	assert.Empty(t, c.sigmaValues)
+	assert.NoError(t, c.RunTokens([]string{"1", "2", "s+", "2", "2", "S+", "9", "2", "S+", "9", "2", "S-", "3", "2", "S+", "linreg"}))
+	assert.Equal(t, []string{"0", "2", "0"}, c.GetStackString())
+
+	// same x
+	assert.NoError(t, c.Run("CLΣ"))
+	assert.Empty(t, c.sigmaValues)
+	assert.NoError(t, c.RunTokens([]string{"1", "1", "Σ+", "1", "2", "Σ+"}))
+	assert.EqualError(t, c.Run("LINREG"), "no variation in x")
+}
@@ -254,3 +254,251 @@ var (
 	Rose900    = lipgloss.Color("#881337") // #881337
 	Rose950    = lipgloss.Color("#4c0519") // #4c0519
 )
+
+// by name, like "blue-400"
+var TailwindColors = map[string]lipgloss.Color{
+	"black":       Black,
+	"white":       White,
+	"slate-50":    Slate50,
+	"slate-100":   Slate100,
+	"slate-200":   Slate200,
+	"slate-300":   Slate300,
+	"slate-400":   Slate400,
+	"slate-500":   Slate500,
+	"slate-600":   Slate600,
+	"slate-700":   Slate700,
+	"slate-800":   Slate800,
+	"slate-900":   Slate900,
+	"slate-950":   Slate950,
+	"gray-50":     Gray50,
+	"gray-100":    Gray100,
+	"gray-200":    Gray200,
+	"gray-300":    Gray300,
+	"gray-400":    Gray400,
+	"gray-500":    Gray500,
+	"gray-600":    Gray600,
+	"gray-700":    Gray700,
+	"gray-800":    Gray800,
+	"gray-900":    Gray900,
+	"gray-950":    Gray950,
+	"zinc-50":     Zinc50,
+	"zinc-100":    Zinc100,
+	"zinc-200":    Zinc200,
+	"zinc-300":    Zinc300,
+	"zinc-400":    Zinc400,
+	"zinc-500":    Zinc500,
+	"zinc-600":    Zinc600,
+	"zinc-700":    Zinc700,
+	"zinc-800":    Zinc800,
+	"zinc-900":    Zinc900,
+	"zinc-950":    Zinc950,
+	"neutral-50":  Neutral50,
+	"neutral-100": Neutral100,
+	"neutral-200": Neutral200,
+	"neutral-300": Neutral300,
+	"neutral-400": Neutral400,
+	"neutral-500": Neutral500,
+	"neutral-600": Neutral600,
+	"neutral-700": Neutral700,
+	"neutral-800": Neutral800,
+	"neutral-900": Neutral900,
+	"neutral-950": Neutral950,
+	"stone-50":    Stone50,
+	"stone-100":   Stone100,
+	"stone-200":   Stone200,
+	"stone-300":   Stone300,
+	"stone-400":   Stone400,
+	"stone-500":   Stone500,
+	"stone-600":   Stone600,
+	"stone-700":   Stone700,
+	"stone-800":   Stone800,
+	"stone-900":   Stone900,
+	"stone-950":   Stone950,
+	"red-50":      Red50,
+	"red-100":     Red100,
+	"red-200":     Red200,
+	"red-300":     Red300,
+	"red-400":     Red400,
+	"red-500":     Red500,
+	"red-600":     Red600,
+	"red-700":     Red700,
+	"red-800":     Red800,
+	"red-900":     Red900,
+	"red-950":     Red950,
+	"orange-50":   Orange50,
+	"orange-100":  Orange100,
+	"orange-200":  Orange200,
+	"orange-300":  Orange300,
+	"orange-400":  Orange400,
+	"orange-500":  Orange500,
+	"orange-600":  Orange600,
+	"orange-700":  Orange700,
+	"orange-800":  Orange800,
+	"orange-900":  Orange900,
+	"orange-950":  Orange950,
+	"amber-50":    Amber50,
+	"amber-100":   Amber100,
+	"amber-200":   Amber200,
+	"amber-300":   Amber300,
+	"amber-400":   Amber400,
+	"amber-500":   Amber500,
+	"amber-600":   Amber600,
+	"amber-700":   Amber700,
+	"amber-800":   Amber800,
+	"amber-900":   Amber900,
+	"amber-950":   Amber950,
+	"yellow-50":   Yellow50,
+	"yellow-100":  Yellow100,
+	"yellow-200":  Yellow200,
+	"yellow-300":  Yellow300,
+	"yellow-400":  Yellow400,
+	"yellow-500":  Yellow500,
+	"yellow-600":  Yellow600,
+	"yellow-700":  Yellow700,
+	"yellow-800":  Yellow800,
+	"yellow-900":  Yellow900,
+	"yellow-950":  Yellow950,
+	"lime-50":     Lime50,
+	"lime-100":    Lime100,
+	"lime-200":    Lime200,
+	"lime-300":    Lime300,
+	"lime-400":    Lime400,
+	"lime-500":    Lime500,
+	"lime-600":    Lime600,
+	"lime-700":    Lime700,
+	"lime-800":    Lime800,
+	"lime-900":    Lime900,
+	"lime-950":    Lime950,
+	"green-50":    Green50,
+	"green-100":   Green100,
+	"green-200":   Green200,
+	"green-300":   Green300,
+	"green-400":   Green400,
+	"green-500":   Green500,
+	"green-600":   Green600,
+	"green-700":   Green700,
+	"green-800":   Green800,
+	"green-900":   Green900,
+	"green-950":   Green950,
+	"emerald-50":  Emerald50,
+	"emerald-100": Emerald100,
+	"emerald-200": Emerald200,
+	"emerald-300": Emerald300,
+	"emerald-400": Emerald400,
+	"emerald-500": Emerald500,
+	"emerald-600": Emerald600,
+	"emerald-700": Emerald700,
+	"emerald-800": Emerald800,
+	"emerald-900": Emerald900,
+	"emerald-950": Emerald950,
+	"teal-50":     Teal50,
+	"teal-100":    Teal100,
+	"teal-200":    Teal200,
+	"teal-300":    Teal300,
+	"teal-400":    Teal400,
+	"teal-500":    Teal500,
+	"teal-600":    Teal600,
+	"teal-700":    Teal700,
+	"teal-800":    Teal800,
+	"teal-900":    Teal900,
+	"teal-950":    Teal950,
+	"cyan-50":     Cyan50,
+	"cyan-100":    Cyan100,
+	"cyan-200":    Cyan200,
+	"cyan-300":    Cyan300,
+	"cyan-400":    Cyan400,
+	"cyan-500":    Cyan500,
+	"cyan-600":    Cyan600,
+	"cyan-700":    Cyan700,
+	"cyan-800":    Cyan800,
+	"cyan-900":    Cyan900,
+	"cyan-950":    Cyan950,
+	"sky-50":      Sky50,
+	"sky-100":     Sky100,
+	"sky-200":     Sky200,
+	"sky-300":     Sky300,
+	"sky-400":     Sky400,
+	"sky-500":     Sky500,
+	"sky-600":     Sky600,
+	"sky-700":     Sky700,
+	"sky-800":     Sky800,
+	"sky-900":     Sky900,
+	"sky-950":     Sky950,
+	"blue-50":     Blue50,
+	"blue-100":    Blue100,
+	"blue-200":    Blue200,
+	"blue-300":    Blue300,
+	"blue-400":    Blue400,
+	"blue-500":    Blue500,
+	"blue-600":    Blue600,
+	"blue-700":    Blue700,
+	"blue-800":    Blue800,
+	"blue-900":    Blue900,
+	"blue-950":    Blue950,
+	"indigo-50":   Indigo50,
+	"indigo-100":  Indigo100,
+	"indigo-200":  Indigo200,
+	"indigo-300":  Indigo300,
+	"indigo-400":  Indigo400,
+	"indigo-500":  Indigo500,
+	"indigo-600":  Indigo600,
+	"indigo-700":  Indigo700,
+	"indigo-800":  Indigo800,
+	"indigo-900":  Indigo900,
+	"indigo-950":  Indigo950,
+	"violet-50":   Violet50,
+	"violet-100":  Violet100,
+	"violet-200":  Violet200,
+	"violet-300":  Violet300,
+	"violet-400":  Violet400,
+	"violet-500":  Violet500,
+	"violet-600":  Violet600,
+	"violet-700":  Violet700,
+	"violet-800":  Violet800,
+	"violet-900":  Violet900,
+	"violet-950":  Violet950,
+	"purple-50":   Purple50,
+	"purple-100":  Purple100,
+	"purple-200":  Purple200,
+	"purple-300":  Purple300,
+	"purple-400":  Purple400,
+	"purple-500":  Purple500,
+	"purple-600":  Purple600,
+	"purple-700":  Purple700,
+	"purple-800":  Purple800,
+	"purple-900":  Purple900,
+	"purple-950":  Purple950,
+	"fuchsia-50":  Fuchsia50,
+	"fuchsia-100": Fuchsia100,
+	"fuchsia-200": Fuchsia200,
+	"fuchsia-300": Fuchsia300,
+	"fuchsia-400": Fuchsia400,
+	"fuchsia-500": Fuchsia500,
+	"fuchsia-600": Fuchsia600,
+	"fuchsia-700": Fuchsia700,
+	"fuchsia-800": Fuchsia800,
+	"fuchsia-900": Fuchsia900,
+	"fuchsia-950": Fuchsia950,
+	"pink-50":     Pink50,
+	"pink-100":    Pink100,
+	"pink-200":    Pink200,
+	"pink-300":    Pink300,
+	"pink-400":    Pink400,
+	"pink-500":    Pink500,
+	"pink-600":    Pink600,
+	"pink-700":    Pink700,
+	"pink-800":    Pink800,
+	"pink-900":    Pink900,
+	"pink-950":    Pink950,
+	"rose-50":     Rose50,
+	"rose-100":    Rose100,
+	"rose-200":    Rose200,
+	"rose-300":    Rose300,
+	"rose-400":    Rose400,
+	"rose-500":    Rose500,
+	"rose-600":    Rose600,
+	"rose-700":    Rose700,
+	"rose-800":    Rose800,
+	"rose-900":    Rose900,
+	"rose-950":    Rose950,
+}
@@ -0,0 +1,302 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+)
+
+//
+// Themes. Every style in constants.go is derived from a Theme, see SetTheme.
+// User themes are yaml, with tailwind names like "blue-400" or hex colors.
+// Anything missing comes from the default theme:
+//
+//   border: sky-500
+//   status: sky-700
+//   gradient: [slate-600, slate-500, slate-400, slate-300, slate-200, white]
+//
+
+type Theme struct {
+	Border       lipgloss.TerminalColor
+	BorderTitle  lipgloss.TerminalColor
+	Error        lipgloss.TerminalColor
+	Say          lipgloss.TerminalColor
+	Index        lipgloss.TerminalColor
+	Cursor       lipgloss.TerminalColor
+	Help         lipgloss.TerminalColor
+	HelpKey      lipgloss.TerminalColor
+	HelpCategory lipgloss.TerminalColor
+	Selected     lipgloss.TerminalColor
+	Status       lipgloss.TerminalColor
+	Banner       lipgloss.TerminalColor
+	Cramped      lipgloss.TerminalColor
+	CrampedText  lipgloss.TerminalColor
+	// text on top of colored backgrounds (error, say, selected, status)
+	Text lipgloss.TerminalColor
+	// stack, from the top line down to 1:. Same len as StackSize
+	Gradient []lipgloss.TerminalColor
+	// one color per letter of "Vectro"
+	Title []lipgloss.TerminalColor
+	// use reverse video instead of background colors
+	Reverse bool
+}
+
+var DefaultTheme = Theme{
+	Border:       Blue400,
+	BorderTitle:  Gray400,
+	Error:        Red600,
+	Say:          Green500,
+	Index:        Gray600,
+	Cursor:       lipgloss.AdaptiveColor{Light: string(Yellow500), Dark: string(Yellow300)},
+	Help:         Gray700,
+	HelpKey:      Green500,
+	HelpCategory: Blue400,
+	Selected:     Blue600,
+	Status:       Blue600,
+	Banner:       Yellow400,
+	Cramped:      Violet900,
+	CrampedText:  Gray400,
+	Text:         White,
+	Gradient: []lipgloss.TerminalColor{
+		lipgloss.AdaptiveColor{Light: string(Gray200), Dark: string(Gray600)},
+		lipgloss.AdaptiveColor{Light: string(Gray300), Dark: string(Gray500)},
+		lipgloss.AdaptiveColor{Light: string(Gray400), Dark: string(Gray400)},
+		lipgloss.AdaptiveColor{Light: string(Gray500), Dark: string(Gray300)},
+		lipgloss.AdaptiveColor{Light: string(Gray600), Dark: string(Gray200)},
+		lipgloss.AdaptiveColor{Light: string(Black), Dark: string(White)},
+	},
+	Title: []lipgloss.TerminalColor{
+		Red600, Yellow600, Blue600, Green600, Orange600, Purple500,
+	},
+}
+
+// catppuccin mocha, https://catppuccin.com/palette
+var catppuccin = func() Theme {
+	c := func(hex string) lipgloss.Color { return lipgloss.Color(hex) }
+	return Theme{
+		Border:       c("#b4befe"), // lavender
+		BorderTitle:  c("#a6adc8"), // subtext0
+		Error:        c("#f38ba8"), // red
+		Say:          c("#a6e3a1"), // green
+		Index:        c("#6c7086"), // overlay0
+		Cursor:       c("#f5e0dc"), // rosewater
+		Help:         c("#bac2de"), // subtext1
+		HelpKey:      c("#a6e3a1"), // green
+		HelpCategory: c("#89b4fa"), // blue
+		Selected:     c("#cba6f7"), // mauve
+		Status:       c("#89b4fa"), // blue
+		Banner:       c("#f9e2af"), // yellow
+		Cramped:      c("#313244"), // surface0
+		CrampedText:  c("#cdd6f4"), // text
+		Text:         c("#11111b"), // crust
+		Gradient: []lipgloss.TerminalColor{
+			c("#585b70"), c("#7f849c"), c("#9399b2"), c("#a6adc8"), c("#bac2de"), c("#cdd6f4"),
+		},
+		Title: []lipgloss.TerminalColor{
+			c("#f38ba8"), c("#fab387"), c("#f9e2af"), c("#a6e3a1"), c("#89b4fa"), c("#cba6f7"),
+		},
+	}
+}()
+
+// solarized dark, https://ethanschoonover.com/solarized
+var solarized = func() Theme {
+	c := func(hex string) lipgloss.Color { return lipgloss.Color(hex) }
+	return Theme{
+		Border:       c("#268bd2"), // blue
+		BorderTitle:  c("#93a1a1"), // base1
+		Error:        c("#dc322f"), // red
+		Say:          c("#859900"), // green
+		Index:        c("#586e75"), // base01
+		Cursor:       c("#b58900"), // yellow
+		Help:         c("#839496"), // base0
+		HelpKey:      c("#2aa198"), // cyan
+		HelpCategory: c("#268bd2"), // blue
+		Selected:     c("#6c71c4"), // violet
+		Status:       c("#073642"), // base02
+		Banner:       c("#b58900"), // yellow
+		Cramped:      c("#073642"), // base02
+		CrampedText:  c("#93a1a1"), // base1
+		Text:         c("#eee8d5"), // base2
+		Gradient: []lipgloss.TerminalColor{
+			c("#073642"), c("#586e75"), c("#657b83"), c("#839496"), c("#93a1a1"), c("#eee8d5"),
+		},
+		Title: []lipgloss.TerminalColor{
+			c("#dc322f"), c("#cb4b16"), c("#b58900"), c("#859900"), c("#268bd2"), c("#d33682"),
+		},
+	}
+}()
+
+// no colors at all
+var mono = func() Theme {
+	none := lipgloss.NoColor{}
+	return Theme{
+		Border: none, BorderTitle: none, Error: none, Say: none, Index: none,
+		Cursor: none, Help: none, HelpKey: none, HelpCategory: none, Selected: none,
+		Status: none, Banner: none, Cramped: none, CrampedText: none, Text: none,
+		Gradient: []lipgloss.TerminalColor{none, none, none, none, none, none},
+		Title:    []lipgloss.TerminalColor{none, none, none, none, none, none},
+		Reverse:  true,
+	}
+}()
+
+// built-in themes, see --theme
+var Themes = map[string]Theme{
+	"catppuccin": catppuccin,
+	"default":    DefaultTheme,
+	"mono":       mono,
+	"solarized":  solarized,
+}
+
+// the yaml version of a Theme
+type themeYAML struct {
+	Border       string   `yaml:"border"`
+	BorderTitle  string   `yaml:"border_title"`
+	Error        string   `yaml:"error"`
+	Say          string   `yaml:"say"`
+	Index        string   `yaml:"index"`
+	Cursor       string   `yaml:"cursor"`
+	Help         string   `yaml:"help"`
+	HelpKey      string   `yaml:"help_key"`
+	HelpCategory string   `yaml:"help_category"`
+	Selected     string   `yaml:"selected"`
+	Status       string   `yaml:"status"`
+	Banner       string   `yaml:"banner"`
+	Cramped      string   `yaml:"cramped"`
+	CrampedText  string   `yaml:"cramped_text"`
+	Text         string   `yaml:"text"`
+	Gradient     []string `yaml:"gradient"`
+	Title        []string `yaml:"title"`
+	Reverse      bool     `yaml:"reverse"`
+}
+
+// parse a yaml theme, starting from DefaultTheme
+func ParseTheme(data []byte) (Theme, error) {
+	var y themeYAML
+	if err := yaml.Unmarshal(data, &y); err != nil {
+		return Theme{}, err
+	}
+
+	var errs []error
+	t := DefaultTheme
+	color := func(dst *lipgloss.TerminalColor, name string) {
+		if name == "" {
+			return
+		}
+		c, err := ThemeColor(name)
+		errs = append(errs, err)
+		*dst = c
+	}
+	colors := func(dst *[]lipgloss.TerminalColor, names []string, field string) {
+		if names == nil {
+			return
+		}
+		if len(names) != len(*dst) {
+			errs = append(errs, fmt.Errorf("%s needs %d colors", field, len(*dst)))
+			return
+		}
+		*dst = make([]lipgloss.TerminalColor, len(names))
+		for ii, name := range names {
+			color(&(*dst)[ii], name)
+		}
+	}
+
+	color(&t.Border, y.Border)
+	color(&t.BorderTitle, y.BorderTitle)
+	color(&t.Error, y.Error)
+	color(&t.Say, y.Say)
+	color(&t.Index, y.Index)
+	color(&t.Cursor, y.Cursor)
+	color(&t.Help, y.Help)
+	color(&t.HelpKey, y.HelpKey)
+	color(&t.HelpCategory, y.HelpCategory)
+	color(&t.Selected, y.Selected)
+	color(&t.Status, y.Status)
+	color(&t.Banner, y.Banner)
+	color(&t.Cramped, y.Cramped)
+	color(&t.CrampedText, y.CrampedText)
+	color(&t.Text, y.Text)
+	colors(&t.Gradient, y.Gradient, "gradient")
+	colors(&t.Title, y.Title, "title")
+	t.Reverse = y.Reverse
+	return t, errors.Join(errs...)
+}
+
+// "blue-400", "#60a5fa" or "none"
+func ThemeColor(name string) (lipgloss.TerminalColor, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case name == "none":
+		return lipgloss.NoColor{}, nil
+	case strings.HasPrefix(name, "#"):
+		if _, ok := ParseColor(name); !ok {
+			return nil, fmt.Errorf("bad color %q", name)
+		}
+		return lipgloss.Color(name), nil
+	}
+	if c, ok := TailwindColors[name]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown color %q", name)
+}
+
+// rebuild all of the styles from a theme
+func SetTheme(t Theme) {
+	// backgrounds, or reverse video for themes without colors
+	bg := func(c lipgloss.TerminalColor) lipgloss.Style {
+		if t.Reverse {
+			return LG.Reverse(true)
+		}
+		return LG.Foreground(t.Text).Background(c)
+	}
+
+	// panes & borders
+	PaneStyle = LG.Padding(1, 2).Border(lipgloss.RoundedBorder()).BorderForeground(t.Border)
+	BorderStyle = LG.Foreground(t.Border)
+	BorderTitleStyle = LG.Foreground(t.BorderTitle)
+
+	// stack
+	ErrorStyle = bg(t.Error)
+	SayStyle = bg(t.Say)
+	StackStyle = PaneStyle.PaddingTop(2).PaddingBottom(1)
+	IndexStyle = LG.Foreground(t.Index)
+	CursorStyle = LG.Foreground(t.Cursor)
+	StackSelectedStyle = bg(t.Selected)
+
+	// help
+	HelpStyle = LG.Foreground(t.Help)
+	HelpKeyStyle = LG.Foreground(t.HelpKey).Bold(true)
+	HelpCategoryStyle = LG.Foreground(t.HelpCategory).Bold(true)
+
+	// palette
+	PaletteSelectedStyle = bg(t.Selected)
+
+	// status
+	StatusStyle = bg(t.Status).
+		Bold(true).
+		AlignHorizontal(lipgloss.Center).
+		Padding(0, 1)
+
+	// vhs banner
+	BannerStyle = LG.
+		AlignHorizontal(lipgloss.Center).
+		AlignVertical(lipgloss.Center).
+		Foreground(t.Banner).
+		Bold(true).
+		Padding(0, 10)
+
+	// cramped
+	CrampedStyle = LG.
+		Foreground(t.CrampedText).
+		Background(t.Cramped).
+		Bold(true).
+		AlignHorizontal(lipgloss.Center).
+		AlignVertical(lipgloss.Center).
+		Padding(0, 3)
+
+	GradientColors, TitleColors = t.Gradient, t.Title
+	GradientStyles = MapV(GradientColors, LG.Foreground)
+	TitleStyles = MapV(TitleColors, LG.Foreground)
+}
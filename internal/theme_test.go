@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTheme(t *testing.T) {
+	theme, err := ParseTheme([]byte("border: sky-500\nstatus: '#123456'\ntitle: [red-500, none, red-500, red-500, red-500, red-500]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, Sky500, theme.Border)
+	assert.Equal(t, lipgloss.Color("#123456"), theme.Status)
+	assert.Equal(t, lipgloss.NoColor{}, theme.Title[1])
+	assert.Equal(t, DefaultTheme.Error, theme.Error)
+
+	_, err = ParseTheme([]byte("border: sky-1000\nstatus: '#12'\n"))
+	assert.EqualError(t, err, "unknown color \"sky-1000\"\nbad color \"#12\"")
+	_, err = ParseTheme([]byte("gradient: [white]"))
+	assert.EqualError(t, err, "gradient needs 6 colors")
+}
+
+func TestSetTheme(t *testing.T) {
+	defer SetTheme(DefaultTheme)
+
+	for name, theme := range Themes {
+		assert.Len(t, theme.Gradient, StackSize, name)
+		assert.Len(t, theme.Title, len("Vectro"), name)
+	}
+
+	SetTheme(Themes["solarized"])
+	assert.Equal(t, lipgloss.Color("#268bd2"), PaneStyle.GetBorderTopForeground())
+	assert.Equal(t, lipgloss.Color("#268bd2"), StackStyle.GetBorderTopForeground())
+	SetTheme(Themes["mono"])
+	assert.True(t, StatusStyle.GetReverse())
+	assert.Equal(t, lipgloss.NoColor{}, GradientStyles[0].GetForeground())
+}
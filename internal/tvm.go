@@ -0,0 +1,215 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+//
+// Time value of money, like a 12C. N, I/YR, PV, PMT and FV are kept apart from
+// the STO/RCL registers, and SOLVE finds any one of them from the other four.
+// Money in is positive and money out is negative, so a loan has PV > 0 and
+// PMT < 0. I/YR is a yearly percentage, split over P/YR payments per year (12
+// if unset). In BEGIN mode payments happen at the start of each period.
+//
+
+// the TVM values
+const (
+	tvmN   = "N"
+	tvmI   = "I/YR"
+	tvmPV  = "PV"
+	tvmPMT = "PMT"
+	tvmFV  = "FV"
+	tvmPYR = "P/YR"
+)
+
+// the ones SOLVE can find
+var tvmNames = []string{tvmN, tvmI, tvmPV, tvmPMT, tvmFV}
+
+// give up on SOLVE/IRR after this many iterations
+const maxSolveIterations = 200
+
+// AMORT loops once per period, 100 years of weekly payments is plenty
+const maxAmortPeriods = 5200
+
+type tvm struct {
+	n, i, pv, pmt, fv Num // i is the rate per period, 0.01 for 1%
+	pyr               Num
+	begin             bool
+	prec              int
+}
+
+// load the TVM values
+func (c *Calculator) tvm() tvm {
+	r := func(name string) Num { return c.tvmValues[name] }
+	t := tvm{n: r(tvmN), pv: r(tvmPV), pmt: r(tvmPMT), fv: r(tvmFV), pyr: r(tvmPYR), begin: c.begin, prec: c.precision}
+	if t.pyr.IsZero() {
+		t.pyr = decimal.NewFromInt(12)
+	}
+	t.i = Div(r(tvmI), t.pyr.Mul(decimal.NewFromInt(100)), c.precision)
+	return t
+}
+
+// solve for name, saved for SOLVE after validSolve
+func (c *Calculator) solve(name string) (Num, error) {
+	key := fmt.Sprint("SOLVE ", name, " ", c.tvmValues, c.begin, c.precision)
+	return c.solutions.get(key, func() (Num, error) { return c.tvm().solve(name) })
+}
+
+// IRR, saved for IRR after validIRR
+func (c *Calculator) irr(flows []Num) (Num, error) {
+	key := fmt.Sprint("IRR ", flows, c.precision)
+	return c.solutions.get(key, func() (Num, error) { return IRR(flows, c.precision) })
+}
+
+// store one of the TVM values
+func (c *Calculator) storeTVM(name string, x Num) {
+	if c.tvmValues == nil {
+		c.tvmValues = map[string]Num{}
+	}
+	c.tvmValues[name] = x
+}
+
+// solve for one of the TVM values
+func (t tvm) solve(name string) (Num, error) {
+	if name == tvmI {
+		i, err := findRoot(func(i Num) Num { t.i = i; return t.balance() }, decimal.New(1, -2), t.prec)
+		if err != nil || i.LessThanOrEqual(One.Neg()) {
+			return Num{}, errors.New("no solution")
+		}
+		return i.Mul(t.pyr).Mul(decimal.NewFromInt(100)), nil
+	}
+
+	// everything else is closed form
+	if t.i.IsZero() {
+		switch name {
+		case tvmN:
+			if t.pmt.IsZero() {
+				return Num{}, errors.New("no solution")
+			}
+			return Div(t.pv.Add(t.fv), t.pmt, t.prec).Neg(), nil
+		case tvmPV:
+			return t.pmt.Mul(t.n).Add(t.fv).Neg(), nil
+		case tvmPMT:
+			if t.n.IsZero() {
+				return Num{}, errors.New("no solution")
+			}
+			return Div(t.pv.Add(t.fv), t.n, t.prec).Neg(), nil
+		}
+		return t.pv.Add(t.pmt.Mul(t.n)).Neg(), nil
+	}
+	g, k := t.growth(), t.annuity()
+	switch name {
+	case tvmN:
+		// (1+i)^n = (k*pmt - fv) / (k*pmt + pv)
+		num, den := k.Mul(t.pmt).Sub(t.fv), k.Mul(t.pmt).Add(t.pv)
+		if den.IsZero() || !Div(num, den, t.prec).IsPositive() {
+			return Num{}, errors.New("no solution")
+		}
+		return Div(Ln(Div(num, den, t.prec), t.prec), Ln(One.Add(t.i), t.prec), t.prec), nil
+	case tvmPV:
+		return Div(t.fv.Add(t.pmt.Mul(k).Mul(g.Sub(One))), g, t.prec).Neg(), nil
+	case tvmPMT:
+		if g.Equal(One) {
+			return Num{}, errors.New("no solution")
+		}
+		return Div(t.fv.Add(t.pv.Mul(g)), k.Mul(g.Sub(One)), t.prec).Neg(), nil
+	}
+	return t.pv.Mul(g).Add(t.pmt.Mul(k).Mul(g.Sub(One))).Neg(), nil
+}
+
+// (1+i)^n
+func (t tvm) growth() Num {
+	return Pow(One.Add(t.i), t.n, t.prec)
+}
+
+// what each payment is worth, (1 + i*begin) / i
+func (t tvm) annuity() Num {
+	k := One
+	if t.begin {
+		k = k.Add(t.i)
+	}
+	return Div(k, t.i, t.prec)
+}
+
+// what's left over at the end, zero when the values balance
+func (t tvm) balance() Num {
+	if t.i.IsZero() {
+		return t.pv.Add(t.pmt.Mul(t.n)).Add(t.fv)
+	}
+	g := t.growth()
+	return t.pv.Mul(g).Add(t.pmt.Mul(t.annuity()).Mul(g.Sub(One))).Add(t.fv)
+}
+
+// pay off n periods of the loan, returning the interest and principal paid.
+// Assumes END mode
+func (t tvm) amortize(n int) (interest, principal, balance Num) {
+	balance = t.pv
+	for range n {
+		x := balance.Mul(t.i).Neg()
+		interest, principal = interest.Add(x), principal.Add(t.pmt.Sub(x))
+		balance = balance.Add(t.pmt.Sub(x))
+	}
+	return interest, principal, balance
+}
+
+//
+// cash flows
+//
+
+// net present value of cash flows, the first one is now. rate is a
+// percentage per period
+func NPV(flows []Num, rate Num, prec int) Num {
+	return presentValue(flows, Div(rate, decimal.NewFromInt(100), prec), prec)
+}
+
+// internal rate of return, as a percentage per period
+func IRR(flows []Num, prec int) (Num, error) {
+	r, err := findRoot(func(r Num) Num { return presentValue(flows, r, prec) }, decimal.New(1, -1), prec)
+	if err != nil || r.LessThanOrEqual(One.Neg()) {
+		return Num{}, errors.New("no solution")
+	}
+	return r.Mul(decimal.NewFromInt(100)), nil
+}
+
+// sum of the discounted flows, r is the rate per period
+func presentValue(flows []Num, r Num, prec int) Num {
+	var sum Num
+	discount := One
+	for _, flow := range flows {
+		sum = sum.Add(Div(flow, discount, prec))
+		discount = discount.Mul(One.Add(r))
+	}
+	return sum
+}
+
+//
+// helpers
+//
+
+// find x where f(x) = 0 with the secant method, starting near x0
+func findRoot(f func(Num) Num, x0 Num, prec int) (Num, error) {
+	tolerance := decimal.New(1, int32(-prec-2)) //nolint:gosec
+	x1 := x0.Mul(decimal.NewFromFloat(1.1))
+	f0, f1 := f(x0), f(x1)
+	for range maxSolveIterations {
+		if f1.IsZero() {
+			return x1, nil
+		}
+		if f1.Equal(f0) {
+			break
+		}
+		x2 := x1.Sub(Div(f1.Mul(x1.Sub(x0)), f1.Sub(f0), prec))
+		if x2.LessThanOrEqual(One.Neg()) {
+			break // rates can't go below -100%
+		}
+		if x2.Sub(x1).Abs().LessThan(tolerance) {
+			return x2, nil
+		}
+		x0, f0 = x1, f1
+		x1, f1 = x2, f(x2)
+	}
+	return Num{}, errors.New("no solution")
+}
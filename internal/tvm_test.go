@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTVM(t *testing.T) {
+	c := NewCalculator()
+	solve := func(name string) string {
+		assert.NoError(t, c.RunArg("SOLVE", name))
+		return c.PopValue().String()
+	}
+
+	// 30 year mortgage, monthly
+	assert.NoError(t, c.RunTokens([]string{"360", "N", "6", "I/YR", "200000", "PV", "0", "FV"}))
+	assert.Empty(t, c.GetStack())
+	assert.Equal(t, "-1199.1010503055", solve("PMT"))
+	assert.Equal(t, "-1199.1010503055", c.tvmValues["PMT"].String())
+	assert.Empty(t, c.GetRegisters())
+	assert.Equal(t, "360", solve("N"))
+	assert.Equal(t, "6", solve("I/YR"))
+	assert.Equal(t, "200000", solve("PV"))
+	assert.Equal(t, "0", solve("FV"))
+
+	// begin mode
+	assert.NoError(t, c.Run("BEGIN"))
+	assert.Contains(t, c.GetModes(), "BEGIN")
+	assert.Equal(t, "-1193.1353734383", solve("PMT"))
+	assert.Equal(t, "6", solve("I/YR"))
+	assert.NoError(t, c.Run("BEGIN"))
+
+	// savings, yearly
+	assert.NoError(t, c.Run("CLTVM"))
+	assert.Empty(t, c.tvmValues)
+	assert.NoError(t, c.RunTokens([]string{"1", "P/YR", "10", "N", "5", "I/YR", "-1000", "PV", "-100", "PMT"}))
+	assert.Equal(t, "2886.6838803323", solve("FV"))
+
+	// no interest
+	assert.NoError(t, c.RunTokens([]string{"0", "I/YR", "0", "FV"}))
+	assert.Equal(t, "1000", solve("PV"))
+	assert.NoError(t, c.RunTokens([]string{"2000", "PV"}))
+	assert.Equal(t, "20", solve("N"))
+
+	// registers are separate
+	c.PushInt(5)
+	assert.NoError(t, c.RunArg("STO", "PV"))
+	assert.Equal(t, "2000", c.tvmValues["PV"].String())
+	assert.Equal(t, "20", solve("N"))
+
+	// saved on their own, bad values are skipped
+	values := c.GetTVMString()
+	assert.Equal(t, "2000", values["PV"])
+	c.SetTVMString(map[string]string{"N": "12", "PV": "bogus"})
+	assert.Equal(t, map[string]string{"N": "12"}, c.GetTVMString())
+	c.SetTVMString(values)
+
+	// errors
+	assert.EqualError(t, c.RunArg("SOLVE", "P/YR"), "not a TVM value")
+	assert.NoError(t, c.RunTokens([]string{"0", "PMT", "1000", "PV", "1000", "FV"}))
+	assert.EqualError(t, c.RunArg("SOLVE", "I/YR"), "no solution")
+}
+
+func TestAmort(t *testing.T) {
+	c := NewCalculator()
+	assert.NoError(t, c.RunTokens([]string{"360", "N", "6", "I/YR", "200000", "PV", "-1199.1010503055", "PMT"}))
+
+	// first payment, then the rest of the first year
+	assert.NoError(t, c.RunTokens([]string{"1", "amort"}))
+	assert.Equal(t, []string{"-1000", "-199.1010503055", "199800.8989496945"}, c.GetStackString())
+	assert.Equal(t, "359", c.tvmValues["N"].String())
+	c.Clear()
+	assert.NoError(t, c.RunTokens([]string{"359", "amort"}))
+	assert.Equal(t, "0", NormalizePrec(c.Peek(), 4).String())
+
+	c.PushInt(0)
+	assert.EqualError(t, c.Run("AMORT"), "bad count")
+	c.PushInt(1)
+	assert.EqualError(t, c.Run("AMORT"), "more than N periods")
+	assert.NoError(t, c.RunTokens([]string{"1e9", "N", "1e9"}))
+	assert.EqualError(t, c.Run("AMORT"), "too many periods")
+	c.Clear()
+	assert.NoError(t, c.Run("BEGIN"))
+	c.PushInt(1)
+	assert.EqualError(t, c.Run("AMORT"), "amortization needs END mode")
+}
+
+func TestCashFlows(t *testing.T) {
+	c := NewCalculator()
+	run := func(tokens ...string) []string {
+		c.Clear()
+		assert.NoError(t, c.RunTokens(tokens))
+		return c.GetStackString()
+	}
+
+	assert.Equal(t, []string{"-21.0368144252"}, run("-1000", "300", "400", "500", "npv", "10"))
+	run("-1000", "300", "400", "500", "irr")
+	assert.Equal(t, "8.8963", c.Peek().Round(4).String())
+	assert.Equal(t, "irr(-1000, 300, 400, 500) = "+c.Peek().String()+"%", c.GetHistory()[len(c.GetHistory())-1])
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"100", "200", "irr"}), "IRR: no solution")
+
+	// percentages
+	assert.Equal(t, []string{"30"}, run("200", "15", "%of"))
+	assert.Equal(t, []string{"25"}, run("80", "100", "%ch"))
+	assert.Equal(t, []string{"80 -> 100 = 25%"}, c.GetHistory())
+	assert.Equal(t, []string{"-20"}, run("100", "80", "%ch"))
+	assert.Equal(t, []string{"12.5"}, run("80", "10", "%t"))
+	assert.Equal(t, []string{"125"}, run("100", "25", "markup"))
+	assert.Equal(t, []string{"125"}, run("100", "20", "margin"))
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"0", "5", "%ch"}), "%CH: divide by zero")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"5", "100", "margin"}), "MARGIN: margin must be under 100")
+}
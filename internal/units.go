@@ -0,0 +1,393 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+//
+// Quantities, numbers with units like 5_m/s or 3_KiB. Arithmetic propagates
+// dimensions (m / s is m/s), adding m to s is an error, and CONVERT changes
+// units. Units are named units with powers, kept as typed so 3_ft * 2_m is
+// 6_ft*m. A Quantity that turns out to be dimensionless goes back to being a
+// Num.
+//
+
+type Quantity struct {
+	Value Num
+	Unit  Unit
+}
+
+// a unit like m/s^2, as named units with powers
+type Unit []unitPower
+
+type unitPower struct {
+	name  string
+	power int
+}
+
+// exponents of the base dimensions, see baseUnits
+type dimensions [8]int
+
+// SI base units (and bytes), one per dimension
+var baseUnits = []string{"m", "kg", "s", "A", "K", "mol", "cd", "B"}
+
+// POW on a Quantity stops here, since unit powers that big are surely a
+// mistake
+const maxUnitPower = 12
+
+type unitDef struct {
+	// to base units, base = (x + offset) * factor. Only temperatures have an
+	// offset, and it only applies to CONVERT
+	factor Num
+	offset Num
+	dims   dimensions
+}
+
+var (
+	quantityRe        = regexp.MustCompile(`^([+-]?(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?)_(.+)$`)
+	partialQuantityRe = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)_[\pL°*/^\d-]*$`)
+	unitTermRe        = regexp.MustCompile(`([*/]?)([\pL°]+)(?:\^(-?\d+))?`)
+)
+
+//
+// unit definitions
+//
+
+var units = func() map[string]unitDef {
+	d := func(length, mass, time, current, temp, amount, light, info int) dimensions {
+		return dimensions{length, mass, time, current, temp, amount, light, info}
+	}
+	var (
+		length  = d(1, 0, 0, 0, 0, 0, 0, 0)
+		mass    = d(0, 1, 0, 0, 0, 0, 0, 0)
+		time    = d(0, 0, 1, 0, 0, 0, 0, 0)
+		current = d(0, 0, 0, 1, 0, 0, 0, 0)
+		temp    = d(0, 0, 0, 0, 1, 0, 0, 0)
+		amount  = d(0, 0, 0, 0, 0, 1, 0, 0)
+		light   = d(0, 0, 0, 0, 0, 0, 1, 0)
+		info    = d(0, 0, 0, 0, 0, 0, 0, 1)
+		area    = d(2, 0, 0, 0, 0, 0, 0, 0)
+		volume  = d(3, 0, 0, 0, 0, 0, 0, 0)
+		speed   = d(1, 0, -1, 0, 0, 0, 0, 0)
+		freq    = d(0, 0, -1, 0, 0, 0, 0, 0)
+		force   = d(1, 1, -2, 0, 0, 0, 0, 0)
+		press   = d(-1, 1, -2, 0, 0, 0, 0, 0)
+		energy  = d(2, 1, -2, 0, 0, 0, 0, 0)
+		power   = d(2, 1, -3, 0, 0, 0, 0, 0)
+		charge  = d(0, 0, 1, 1, 0, 0, 0, 0)
+		volts   = d(2, 1, -3, -1, 0, 0, 0, 0)
+		ohms    = d(2, 1, -3, -2, 0, 0, 0, 0)
+	)
+	u := func(factor string, dims dimensions) unitDef {
+		return unitDef{factor: decimal.RequireFromString(factor), dims: dims}
+	}
+	return map[string]unitDef{
+		// length
+		"m": u("1", length), "in": u("0.0254", length), "ft": u("0.3048", length),
+		"yd": u("0.9144", length), "mi": u("1609.344", length), "nmi": u("1852", length),
+		"au": u("149597870700", length), "ly": u("9460730472580800", length),
+		// mass
+		"g": u("0.001", mass), "t": u("1000", mass), "lb": u("0.45359237", mass),
+		"oz": u("0.028349523125", mass), "st": u("6.35029318", mass),
+		// time
+		"s": u("1", time), "min": u("60", time), "h": u("3600", time), "d": u("86400", time),
+		"wk": u("604800", time), "yr": u("31557600", time),
+		// temperature
+		"K":  u("1", temp),
+		"°C": {factor: One, offset: decimal.RequireFromString("273.15"), dims: temp},
+		"°F": {factor: Div(decimal.NewFromInt(5), decimal.NewFromInt(9), MaxPrecision), offset: decimal.RequireFromString("459.67"), dims: temp},
+		// information
+		"B": u("1", info), "b": u("0.125", info),
+		// everything else
+		"A": u("1", current), "mol": u("1", amount), "cd": u("1", light),
+		"ha": u("10000", area), "acre": u("4046.8564224", area),
+		"L": u("0.001", volume), "gal": u("0.003785411784", volume), "qt": u("0.000946352946", volume),
+		"cup": u("0.0002365882365", volume), "floz": u("0.0000295735295625", volume),
+		"mph": u("0.44704", speed), "knot": u("0.514444444444444444444444", speed),
+		"Hz": u("1", freq), "N": u("1", force), "lbf": u("4.4482216152605", force),
+		"Pa": u("1", press), "bar": u("100000", press), "atm": u("101325", press), "psi": u("6894.757293168", press),
+		"J": u("1", energy), "cal": u("4.184", energy), "Wh": u("3600", energy), "eV": u("1.602176634e-19", energy),
+		"W": u("1", power), "hp": u("745.69987158227022", power),
+		"C": u("1", charge), "V": u("1", volts), "Ω": u("1", ohms),
+	}
+}()
+
+// aliases, for keyboards without ° or Ω
+var unitAliases = map[string]string{
+	"degC": "°C", "degF": "°F", "ohm": "Ω", "KB": "kB",
+}
+
+// SI prefixes work on these units, like km, ms, kWh or GB
+var (
+	prefixedUnits = []string{"m", "g", "s", "A", "mol", "L", "Hz", "N", "Pa", "J", "cal", "Wh", "eV", "W", "C", "V", "Ω", "B", "b"}
+	prefixes      = map[string]string{
+		"p": "1e-12", "n": "1e-9", "u": "1e-6", "µ": "1e-6", "m": "0.001", "c": "0.01",
+		"k": "1000", "M": "1e6", "G": "1e9", "T": "1e12",
+		// binary prefixes, for bytes
+		"Ki": "1024", "Mi": "1048576", "Gi": "1073741824", "Ti": "1099511627776",
+	}
+)
+
+// find a unit by name, like "ft" or "km"
+func lookupUnit(name string) (unitDef, bool) {
+	if def, ok := units[name]; ok {
+		return def, true
+	}
+	for prefix, factor := range prefixes {
+		base, ok := strings.CutPrefix(name, prefix)
+		if !ok || !slices.Contains(prefixedUnits, base) {
+			continue
+		}
+		if strings.HasSuffix(prefix, "i") && base != "B" && base != "b" {
+			continue // KiB, but not Kim
+		}
+		def := units[base]
+		def.factor = def.factor.Mul(decimal.RequireFromString(factor))
+		return def, true
+	}
+	return unitDef{}, false
+}
+
+//
+// units
+//
+
+// parse "m/s^2" or "kg*m/s^2". Each / divides by the next term only, so
+// J/kg/K is J/(kg*K)
+func ParseUnit(s string) (Unit, error) {
+	if strings.HasPrefix(s, "1/") {
+		s = s[1:] // 1/s
+	}
+	matches := unitTermRe.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 || strings.Join(lo.Map(matches, func(m []string, _ int) string { return m[0] }), "") != s {
+		return nil, fmt.Errorf("bad unit %q", s)
+	}
+
+	var unit Unit
+	for ii, m := range matches {
+		op, name, power := m[1], m[2], 1
+		if (ii == 0 && op == "*") || (ii > 0 && op == "") {
+			return nil, fmt.Errorf("bad unit %q", s)
+		}
+		name = lo.CoalesceOrEmpty(unitAliases[name], name)
+		if _, ok := lookupUnit(name); !ok {
+			return nil, fmt.Errorf("unknown unit %q", m[2])
+		}
+		if m[3] != "" {
+			power = lo.Must(strconv.Atoi(m[3]))
+		}
+		if op == "/" {
+			power = -power
+		}
+		unit = unit.mul(Unit{{name: name, power: power}})
+	}
+	return unit, nil
+}
+
+func (u Unit) String() string {
+	var num, denom []string
+	for _, p := range u {
+		switch {
+		case p.power == 1:
+			num = append(num, p.name)
+		case p.power > 1:
+			num = append(num, fmt.Sprintf("%s^%d", p.name, p.power))
+		case p.power == -1:
+			denom = append(denom, p.name)
+		default:
+			denom = append(denom, fmt.Sprintf("%s^%d", p.name, -p.power))
+		}
+	}
+	s := lo.CoalesceOrEmpty(strings.Join(num, "*"), lo.Ternary(len(denom) > 0, "1", ""))
+	for _, d := range denom {
+		s += "/" + d
+	}
+	return s
+}
+
+// multiply units, adding the powers of units with the same name
+func (u Unit) mul(v Unit) Unit {
+	result := slices.Clone(u)
+	for _, p := range v {
+		if ii := slices.IndexFunc(result, func(q unitPower) bool { return q.name == p.name }); ii != -1 {
+			result[ii].power += p.power
+		} else {
+			result = append(result, p)
+		}
+	}
+	return lo.Filter(result, func(p unitPower, _ int) bool { return p.power != 0 })
+}
+
+// raise to a power
+func (u Unit) pow(n int) Unit {
+	return lo.Filter(MapV(u, func(p unitPower) unitPower { return unitPower{name: p.name, power: p.power * n} }),
+		func(p unitPower, _ int) bool { return p.power != 0 })
+}
+
+// the factor to base units, and the dimensions
+func (u Unit) base() (Num, dimensions) {
+	var d dimensions
+	factor := One
+	for _, p := range u {
+		def, _ := lookupUnit(p.name)
+		f := Pow(def.factor, decimal.NewFromInt(int64(max(p.power, -p.power))), MaxPrecision)
+		factor = lo.Ternary(p.power > 0, factor.Mul(f), Div(factor, f, MaxPrecision))
+		for ii := range d {
+			d[ii] += def.dims[ii] * p.power
+		}
+	}
+	return factor, d
+}
+
+func (u Unit) dims() dimensions {
+	_, d := u.base()
+	return d
+}
+
+// just one temperature unit, like °F? Those convert with offsets
+func (u Unit) temperature() (unitDef, bool) {
+	if len(u) != 1 || u[0].power != 1 {
+		return unitDef{}, false
+	}
+	def, _ := lookupUnit(u[0].name)
+	return def, def.dims == units["K"].dims
+}
+
+//
+// quantities
+//
+
+// a Quantity, or a Num if the unit is dimensionless
+func QuantityValue(x Num, unit Unit) Number {
+	factor, d := unit.base()
+	if d == (dimensions{}) {
+		return x.Mul(factor)
+	}
+	return Quantity{Value: x, Unit: unit}
+}
+
+// parse "5_m/s"
+func ParseQuantity(s string) (Value, error) {
+	m := quantityRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, errors.New("invalid quantity")
+	}
+	unit, err := ParseUnit(m[2])
+	if err != nil {
+		return nil, err
+	}
+	return QuantityValue(lo.Must(decimal.NewFromString(m[1])), unit), nil
+}
+
+// is this the start of a quantity? Used to decide if a letter belongs in the
+// text input, like the "m" in "5_m"
+func IsPartialQuantity(s string) bool {
+	return partialQuantityRe.MatchString(s)
+}
+
+// is this value a Quantity?
+func IsQuantity(v Value) bool {
+	_, ok := v.(Quantity)
+	return ok
+}
+
+// convert a real number to a dimensionless Quantity
+func toQuantity(v Value) Quantity {
+	if q, ok := v.(Quantity); ok {
+		return q
+	}
+	return Quantity{Value: ToNum(v)}
+}
+
+func (q Quantity) String() string {
+	return q.Value.String() + "_" + q.Unit.String()
+}
+
+func (q Quantity) IsZero() bool {
+	return q.Value.IsZero()
+}
+
+// convert to another unit with the same dimensions
+func (q Quantity) Convert(unit Unit, prec int) (Number, error) {
+	from, fromDims := q.Unit.base()
+	to, toDims := unit.base()
+	if fromDims != toDims {
+		return nil, errors.New("incompatible units")
+	}
+	if a, ok := q.Unit.temperature(); ok {
+		if b, ok := unit.temperature(); ok {
+			k := q.Value.Add(a.offset).Mul(a.factor)
+			return QuantityValue(Div(k, b.factor, prec).Sub(b.offset), unit), nil
+		}
+	}
+	return QuantityValue(Div(q.Value.Mul(from), to, prec), unit), nil
+}
+
+// convert to SI base units
+func (q Quantity) Base(prec int) Number {
+	var unit Unit
+	for ii, power := range q.Unit.dims() {
+		if power != 0 {
+			unit = append(unit, unitPower{name: baseUnits[ii], power: power})
+		}
+	}
+	return lo.Must(q.Convert(unit, prec))
+}
+
+// add r, in our unit. Temperatures are differences here, so no offsets
+func (q Quantity) Add(r Quantity, prec int) Number {
+	from, _ := r.Unit.base()
+	to, _ := q.Unit.base()
+	return QuantityValue(q.Value.Add(Div(r.Value.Mul(from), to, prec)), q.Unit)
+}
+
+func (q Quantity) Neg() Quantity {
+	return Quantity{Value: q.Value.Neg(), Unit: q.Unit}
+}
+
+func (q Quantity) Mul(r Quantity) Number {
+	return QuantityValue(q.Value.Mul(r.Value), q.Unit.mul(r.Unit))
+}
+
+func (q Quantity) Div(r Quantity, prec int) Number {
+	return QuantityValue(Div(q.Value, r.Value, prec), q.Unit.mul(r.Unit.pow(-1)))
+}
+
+func (q Quantity) Pow(n int, prec int) Number {
+	return QuantityValue(Pow(q.Value, decimal.NewFromInt(int64(n)), prec), q.Unit.pow(n))
+}
+
+// square root, if all of the powers are even
+func (q Quantity) Sqrt(prec int) (Number, error) {
+	var unit Unit
+	for _, p := range q.Unit {
+		if p.power%2 != 0 {
+			return nil, errors.New("odd unit powers")
+		}
+		unit = append(unit, unitPower{name: p.name, power: p.power / 2})
+	}
+	return QuantityValue(Pow(q.Value, Half, prec), unit), nil
+}
+
+// can these two be combined? Units need real numbers, and add/sub need the
+// same dimensions
+func unitsError(a, b Value, same bool) error {
+	if !IsQuantity(a) && !IsQuantity(b) {
+		return nil
+	}
+	if IsComplex(a) || IsComplex(b) {
+		return errors.New("complex numbers can't have units")
+	}
+	if same && toQuantity(a).Unit.dims() != toQuantity(b).Unit.dims() {
+		return errors.New("incompatible units")
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5_m", "5_m"},
+		{"-2.5_km/h", "-2.5_km/h"},
+		{"9.8_m/s^2", "9.8_m/s^2"},
+		{"1_kg*m/s^2", "1_kg*m/s^2"},
+		{"3_1/s", "3_1/s"},
+		{"2_degC", "2_°C"},
+		{"4_KiB", "4_KiB"},
+		{"5_m/m", "5"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			v, err := ParseValue(tc.input, Dec)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, v.String())
+		})
+	}
+
+	for _, s := range []string{"5_", "5_xyz", "5_m^x", "5_Kim", "_m"} {
+		_, err := ParseValue(s, Dec)
+		assert.Error(t, err, s)
+	}
+
+	assert.True(t, IsPartialValue("5_", Dec))
+	assert.True(t, IsPartialValue("5_m/s^", Dec))
+}
+
+func TestUnits(t *testing.T) {
+	c := NewCalculator()
+	run := func(tokens ...string) []string {
+		c.Clear()
+		assert.NoError(t, c.RunTokens(tokens))
+		return c.GetStackString()
+	}
+
+	// dimensions propagate
+	assert.Equal(t, []string{"25_m/s"}, run("100_m", "4_s", "/"))
+	assert.Equal(t, []string{"6_m^2"}, run("2_m", "3_m", "*"))
+	assert.Equal(t, []string{"10_m"}, run("5_m/s", "2_s", "*"))
+	assert.Equal(t, []string{"-3_m"}, run("3_m", "neg"))
+	assert.Equal(t, []string{"3_m"}, run("-3_m", "abs"))
+	assert.Equal(t, []string{"15_kg"}, run("5_kg", "3", "*"))
+	assert.Equal(t, []string{"8_m^3"}, run("2_m", "3", "^"))
+	assert.Equal(t, []string{"3_m"}, run("9_m^2", "sqrt"))
+
+	// compatible units are converted to the first unit
+	assert.Equal(t, []string{"1.5_km"}, run("1_km", "500_m", "+"))
+	assert.Equal(t, []string{"0.5_km"}, run("1_km", "500_m", "-"))
+
+	// same dimension cancels out
+	assert.Equal(t, []string{"0.3048"}, run("1_ft", "1_m", "/"))
+
+	// incompatible
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1_m", "1_s", "+"}), "ADD: incompatible units")
+	assert.Equal(t, []string{"1_m", "1_s"}, c.GetStackString())
+	assert.EqualError(t, c.RunTokens([]string{"-"}), "SUB: incompatible units")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1_m", "1", "+"}), "ADD: incompatible units")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1_m", "0_s", "/"}), "DIV: divide by zero")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1_m", "0.5", "^"}), "POW: unit powers must be integers")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"2", "1_m", "^"}), "POW: exponent has units")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"2_ft", "200000", "^"}), "POW: unit power too large")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1_m", "sqrt"}), "SQRT: odd unit powers")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1_m", "ln"}), "LN: has units")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1_m", "sin"}), "SIN: not a number")
+	c.Clear()
+	assert.EqualError(t, c.RunTokens([]string{"1_m", "1+1i", "*"}), "MUL: complex numbers can't have units")
+}
+
+func TestConvert(t *testing.T) {
+	c := NewCalculator()
+	convert := func(s, unit string) string {
+		c.Clear()
+		assert.NoError(t, c.RunTokens([]string{s}))
+		assert.NoError(t, c.RunArg("CONVERT", unit))
+		return c.PeekValue().String()
+	}
+
+	assert.Equal(t, "1.524_m", convert("5_ft", "m"))
+	assert.Equal(t, "100_km/h", convert("27.7777777778_m/s", "km/h"))
+	assert.Equal(t, "0_°C", convert("32_°F", "°C"))
+	assert.Equal(t, "212_°F", convert("100_°C", "degF"))
+	assert.Equal(t, "273.15_K", convert("0_°C", "K"))
+	assert.Equal(t, "1024_B", convert("1_KiB", "B"))
+	assert.Equal(t, "8_b", convert("1_B", "b"))
+	assert.Equal(t, "1_N", convert("1_kg*m/s^2", "N"))
+	assert.Equal(t, "1_kg*m/s^2 = 1_N", c.GetHistory()[len(c.GetHistory())-1])
+
+	// errors
+	c.Clear()
+	c.PushInt(5)
+	assert.EqualError(t, c.RunArg("CONVERT", "m"), "no units")
+	assert.NoError(t, c.RunTokens([]string{"5_m"}))
+	assert.EqualError(t, c.RunArg("CONVERT", "s"), "incompatible units")
+	assert.Error(t, c.RunArg("CONVERT", "xyz"))
+
+	// UNIT, UVAL and UBASE
+	c.Clear()
+	c.PushInt(5)
+	assert.NoError(t, c.RunArg("UNIT", "m"))
+	assert.NoError(t, c.RunArg("UNIT", "/s"))
+	assert.Equal(t, "5_m/s", c.PeekValue().String())
+	assert.Equal(t, "5 m/s", c.Format(c.PeekValue()))
+	assert.NoError(t, c.RunTokens([]string{"2_km/h", "ubase", "uval"}))
+	assert.Equal(t, []string{"5_m/s", "0.5555555556"}, c.GetStackString())
+}
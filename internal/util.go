@@ -1,7 +1,7 @@
 package internal
 
 import (
-	"math"
+	"errors"
 	"os"
 	"regexp"
 	"slices"
@@ -22,12 +22,15 @@ type Num = decimal.Decimal
 var (
 	// decimal constants
 	Half    = decimal.NewFromFloat(0.5)
-	Ln10    = decimal.NewFromFloat(math.Log(10))
 	One     = decimal.NewFromFloat(1)
-	Pi      = decimal.NewFromFloat(math.Pi)
-	Epsilon = decimal.NewFromFloat(1e-6)
+	Two     = decimal.NewFromFloat(2)
+	Ten     = decimal.NewFromInt(10)
+	Epsilon = epsilon(Precision)
 )
 
+// extra digits for intermediate results
+const guardDigits = 5
+
 // is this Num an int?
 func IsInt(value Num) bool {
 	return value.Sub(value.Round(0)).Abs().LessThan(Epsilon)
@@ -35,35 +38,119 @@ func IsInt(value Num) bool {
 
 // if x seems to be an Int, round it
 func Normalize(x Num) Num {
-	x = x.Round(int32(Precision)) //nolint:gosec
-	if IsInt(x) {
+	return NormalizePrec(x, Precision)
+}
+
+// Normalize for a given precision. Epsilon scales with precision.
+func NormalizePrec(x Num, prec int) Num {
+	x = x.Round(int32(prec)) //nolint:gosec
+	if x.Sub(x.Round(0)).Abs().LessThan(epsilon(prec)) {
 		x = x.Round(0)
 	}
 	return x
 }
 
+// close enough to an int, 1e-6 for the default precision
+func epsilon(prec int) Num {
+	return decimal.New(1, int32(4-prec)) //nolint:gosec
+}
+
 // x!
-func Factorial(x Num) Num {
-	if x.IsNegative() {
-		panic("factorial of negative number")
-	}
-	if !x.IsInteger() {
-		panic("factorial of non-integer")
+func Factorial(x Num) (Num, error) {
+	if x.IsNegative() || !x.IsInteger() {
+		return Num{}, errors.New("not a positive int")
 	}
 	var acc = One
 	for ii := One; ii.Cmp(x) <= 0; ii = ii.Add(One) {
 		acc = acc.Mul(ii)
 	}
-	return acc
+	return acc, nil
+}
+
+// x / y, to prec places (plus guard digits). Num.Div stops at 16 places.
+func Div(x, y Num, prec int) Num {
+	return x.DivRound(y, int32(prec+guardDigits)) //nolint:gosec
+}
+
+// ln(x), to prec places
+func Ln(x Num, prec int) Num {
+	return lo.Must(x.Ln(int32(prec + guardDigits))) //nolint:gosec
+}
+
+// ln(10), to prec places
+func Ln10(prec int) Num {
+	return cached(&ln10s, prec, func() Num { return Ln(Ten, prec) })
+}
+
+// pi, to prec places. Machin's formula, pi = 16 atan(1/5) - 4 atan(1/239)
+func Pi(prec int) Num {
+	return cached(&pis, prec, func() Num {
+		places := int32(prec + guardDigits) //nolint:gosec
+		a, b := atanInv(5, places), atanInv(239, places)
+		return a.Mul(decimal.NewFromInt(16)).Sub(b.Mul(decimal.NewFromInt(4))).Round(places)
+	})
+}
+
+// atan(1/n), to places
+func atanInv(n int64, places int32) Num {
+	x := decimal.NewFromInt(n)
+	x2, eps := x.Mul(x), decimal.New(1, -places-1)
+	power := One.DivRound(x, places+1)
+	sum := power
+	for k := int64(1); ; k++ {
+		power = power.DivRound(x2, places+1)
+		term := power.DivRound(decimal.NewFromInt(2*k+1), places+1)
+		if term.LessThan(eps) {
+			return sum
+		}
+		sum = lo.Ternary(k%2 == 1, sum.Sub(term), sum.Add(term))
+	}
 }
 
-// ln(x)
-func Ln(x Num) Num {
-	return lo.Must(x.Ln(8))
+// cache for expensive constants, by precision
+var pis, ln10s map[int]Num
+
+func cached(cache *map[int]Num, prec int, fn func() Num) Num {
+	if *cache == nil {
+		*cache = map[int]Num{}
+	}
+	if x, ok := (*cache)[prec]; ok {
+		return x
+	}
+	x := fn()
+	(*cache)[prec] = x
+	return x
+}
+
+// asin(x), in radians
+func Asin(x Num, prec int) Num {
+	if x.Abs().Equal(One) {
+		return Div(Pi(prec), Two, prec).Mul(decimal.NewFromInt(int64(x.Sign())))
+	}
+	return Div(x, Pow(One.Sub(x.Mul(x)), Half, prec), prec).Atan()
+}
+
+// acos(x), in radians
+func Acos(x Num, prec int) Num {
+	return Div(Pi(prec), Two, prec).Sub(Asin(x, prec))
+}
+
+// atan(y / x), in radians from -pi to pi
+func Atan2(y, x Num, prec int) Num {
+	switch {
+	case x.IsPositive():
+		return Div(y, x, prec).Atan()
+	case x.IsZero():
+		return Div(Pi(prec), Two, prec).Mul(decimal.NewFromInt(int64(y.Sign())))
+	case y.IsNegative():
+		return Div(y, x, prec).Atan().Sub(Pi(prec))
+	}
+	return Div(y, x, prec).Atan().Add(Pi(prec))
 }
 
-func Pow(x, y Num) Num {
-	return lo.Must(x.PowWithPrecision(y, int32(Precision))) //nolint:gosec
+// x ^ y, to prec places
+func Pow(x, y Num, prec int) Num {
+	return lo.Must(x.PowWithPrecision(y, int32(prec+guardDigits))) //nolint:gosec
 }
 
 //
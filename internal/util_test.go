@@ -46,6 +46,23 @@ func TestNormalize(t *testing.T) {
 	}
 }
 
+func TestPrecision(t *testing.T) {
+	const pi50 = "3.14159265358979323846264338327950288419716939937511"
+	const ln2 = "0.69314718055994530941723212145817656807550013436026"
+	assert.Equal(t, pi50, NormalizePrec(Pi(50), 50).String())
+	assert.Equal(t, "3.1415926536", Normalize(Pi(Precision)).String())
+	assert.Equal(t, ln2, NormalizePrec(Ln(Two, 50), 50).String())
+	assert.Equal(t, "2.3025850930", Normalize(Ln10(Precision)).StringFixed(10))
+	sqrt2 := NormalizePrec(Pow(Two, Half, 50), 50)
+	assert.Equal(t, "1.41421356237309504880168872420969807856967187537695", sqrt2.String())
+	assert.Equal(t, "0.33333333333333333333", Div(One, decimal.NewFromInt(3), 20).Round(20).String())
+
+	// epsilon scales with precision
+	x := decimal.RequireFromString("1.0000000000001")
+	assert.Equal(t, "1", Normalize(x).String())
+	assert.Equal(t, "1.0000000000001", NormalizePrec(x, 30).String())
+}
+
 func TestFileExists(t *testing.T) {
 	// Create a temporary file for testing
 	tmpFile, err := os.CreateTemp(t.TempDir(), "test")
@@ -0,0 +1,81 @@
+package internal
+
+import "strings"
+
+//
+// A value on the stack. Usually a Num, but could be something richer like a
+// Frac, a Complex, a Quantity, a Date or a Color. String() should round trip
+// through ParseValue, since that's how the stack is saved.
+//
+
+type Value interface {
+	String() string
+}
+
+// A Value that IsNumber, like a Num, a Frac, a Complex or a Quantity. Fns that
+// take Numbers (instead of Nums) get to see Fracs before they're converted, and
+// handle Complex and Quantity themselves. IsZero is what sets them apart from
+// other Values, since Num already has it. Dates aren't Numbers, so arithmetic
+// that works on dates takes Values instead.
+type Number interface {
+	Value
+	IsZero() bool
+}
+
+// parse a value, like "12", "0xff", "1/3", "3+4i", "5_m/s", "2026-10-18",
+// "3d4h" or "#ff0000". Durations are numbers in hex, so they're decimal only
+func ParseValue(s string, radix Radix) (Value, error) {
+	if color, ok := ParseColor(s); ok {
+		return color, nil
+	}
+	if fracRe.MatchString(s) {
+		return ParseFrac(s)
+	}
+	if complexRe.MatchString(s) {
+		return ParseComplex(s)
+	}
+	if quantityRe.MatchString(s) {
+		return ParseQuantity(s)
+	}
+	if dateRe.MatchString(s) {
+		return ParseDate(s)
+	}
+	if radix == Dec && durationRe.MatchString(s) && strings.TrimLeft(s, "+-") != "" {
+		return ParseDuration(s)
+	}
+	return ParseNum(s, radix)
+}
+
+// could this be the start of a value? Used to decide if a letter belongs in
+// the text input, like the "x" in "0x" or the "m" in "5_m". Durations are
+// only for decimal, like ParseValue
+func IsPartialValue(s string, radix Radix) bool {
+	return IsPartialColor(s) || IsPartialNum(s, radix) || IsPartialQuantity(s) ||
+		IsPartialDate(s) || (radix == Dec && IsPartialDuration(s))
+}
+
+// is this value a real number, a Num or a Frac?
+func IsNum(v Value) bool {
+	switch v.(type) {
+	case Num, Frac:
+		return true
+	}
+	return false
+}
+
+// is this value any kind of number, including Complex and Quantity?
+func IsNumber(v Value) bool {
+	return IsNum(v) || IsComplex(v) || IsQuantity(v)
+}
+
+// convert to a Num, or zero if v isn't a real number. Run checks the types before
+// calling fns, so this is safe in commands.
+func ToNum(v Value) Num {
+	switch v := v.(type) {
+	case Num:
+		return v
+	case Frac:
+		return v.Num()
+	}
+	return Num{}
+}